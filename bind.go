@@ -0,0 +1,212 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// 请求数据标记，嵌入Body的结构体（或其指针）作为处理器参数时，自动根据Content-Type从请求数据中解析
+//
+//	type P struct {
+//		cosine.Body
+//		Name string `json:"name"`
+//	}
+//
+//	func Home(ctx *cosine.Context, p *P) { ... }
+type Body struct{}
+
+// Body类型
+var bodyType = reflect.TypeOf(Body{})
+
+// 判断类型是否为请求数据类型（嵌入了Body的结构体或其指针）
+func isBody(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.Anonymous && f.Type == bodyType {
+			return true
+		}
+	}
+	return false
+}
+
+// 根据Content-Type解析并校验请求数据，结果在本次请求中缓存，解析或校验失败时返回对应的业务异常
+func (self *Context) bindBody(t reflect.Type) (reflect.Value, error) {
+	if v, ok := self.injts[t]; ok {
+		return v, nil
+	}
+
+	elem := t
+	if t.Kind() == reflect.Ptr {
+		elem = t.Elem()
+	}
+	ptr := reflect.New(elem)
+	if err := self.Bind(ptr.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+
+	v := ptr
+	if t.Kind() != reflect.Ptr {
+		v = ptr.Elem()
+	}
+	if self.injts == nil {
+		self.injts = make(injector)
+	}
+	self.injts[t] = v
+	return v, nil
+}
+
+// 根据结构体标签绑定参数并根据validate标签校验
+func bindValues(v interface{}, tag string, get func(name string) []string) error {
+	if err := bindTagged(v, tag, get); err != nil {
+		return err
+	}
+	return Validate(v)
+}
+
+// 根据结构体标签绑定参数，tag为标签名称（如：query），get获取参数名对应的值
+// 没有标签的字段使用字段名，标签为“-”的字段跳过，default标签为参数不存在时的默认值
+// 支持字符串、bool、数字及其指针和切片，有标签的字段为其他类型时panic，没有标签时跳过
+func bindTagged(v interface{}, tag string, get func(name string) []string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		panic("Cosine要求参数绑定的对象必须是结构体指针")
+	}
+	return bindStruct(rv.Elem(), tag, get)
+}
+
+// 绑定结构体字段
+func bindStruct(v reflect.Value, tag string, get func(name string) []string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fv := v.Field(i)
+
+		// 嵌入的结构体
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if err := bindStruct(fv, tag, get); err != nil {
+				return err
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name, tagged := f.Tag.Lookup(tag)
+		if name == "-" {
+			continue
+		}
+		// 不支持的字段类型：有标签时为定义错误（与请求参数无关，第一次绑定时即panic），没有标签时跳过
+		if !bindable(f.Type) {
+			if tagged {
+				panic("Cosine不支持绑定的字段类型：" + t.String() + "." + f.Name + " " + f.Type.String())
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		vals := get(name)
+		if len(vals) == 0 {
+			def, ok := f.Tag.Lookup("default")
+			if !ok {
+				continue
+			}
+			vals = []string{def}
+		}
+		if err := setValue(fv, vals); err != nil {
+			return NewError(400, "参数格式错误："+name)
+		}
+	}
+	return nil
+}
+
+// 判断字段类型是否可以从字符串参数绑定
+func bindable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice:
+		return bindable(t.Elem())
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// 将字符串参数转换成字段类型并赋值
+func setValue(v reflect.Value, vals []string) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		ptr := reflect.New(v.Type().Elem())
+		if err := setValue(ptr.Elem(), vals); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	case reflect.Slice:
+		s := reflect.MakeSlice(v.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := setValue(s.Index(i), []string{val}); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+
+	s := strings.TrimSpace(vals[0])
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(vals[0])
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return errors.New("Cosine不支持绑定的字段类型：" + v.Type().String())
+	}
+	return nil
+}
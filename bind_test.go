@@ -0,0 +1,61 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBindQuery(t *testing.T) {
+	type query struct {
+		Name  string   `query:"name"`
+		Size  int      `query:"size" default:"20"`
+		Tags  []string `query:"tag"`
+		Ptr   *bool    `query:"ptr"`
+		Attrs map[string]string
+		At    time.Time
+	}
+	cos := New()
+	cos.GET("/q", func(ctx *Context) (interface{}, error) {
+		q := new(query)
+		if err := ctx.BindQuery(q); err != nil {
+			return nil, err
+		}
+		return []interface{}{q.Name, q.Size, q.Tags, *q.Ptr, q.Attrs == nil}, nil
+	})
+
+	// 没有标签的不支持类型跳过
+	want := `{"code":200,"message":"","data":["a",20,["x","y"],true,true]}`
+	if got := serve(cos, "GET", "/q?name=a&tag=x&tag=y&ptr=true&Attrs=1&At=1"); got != want {
+		t.Fatalf("got %s", got)
+	}
+	if got := serve(cos, "GET", "/q?size=x"); got != `{"code":400,"message":"参数格式错误：size","data":null}` {
+		t.Fatalf("got %s", got)
+	}
+}
+
+func TestBindUnsupportedTaggedField(t *testing.T) {
+	type query struct {
+		Attrs map[string]string `query:"m"`
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	// 与请求参数无关，第一次绑定时即panic
+	bindTagged(new(query), "query", func(string) []string { return nil })
+}
@@ -0,0 +1,97 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// 请求数据超过大小限制（server.maxbody）
+var ErrBodyTooLarge = NewError(413, "请求数据过大")
+
+// 请求数据读取器，将超过大小限制的错误转换成ErrBodyTooLarge
+// 请求超时或客户端断开连接后（处理器仍在后台执行）不再读取，返回ctx.Err()
+type bodyReader struct {
+	ctx *Context
+	r   io.Reader
+}
+
+// 实现io.Reader接口
+func (self *bodyReader) Read(p []byte) (int, error) {
+	if self.ctx.bodyClosed.Load() {
+		return 0, self.ctx.Err()
+	}
+
+	n, err := self.r.Read(p)
+	// 读取过程中被closeBody中断
+	if self.ctx.bodyClosed.Load() {
+		return 0, self.ctx.Err()
+	}
+	var e *http.MaxBytesError
+	if errors.As(err, &e) {
+		err = ErrBodyTooLarge
+	}
+	return n, err
+}
+
+// 判断请求方法是否需要读取请求数据
+func hasBody(method string) bool {
+	return method != "GET" && method != "HEAD" && method != "DELETE"
+}
+
+// 获取请求数据（第一次调用时读取），读取失败或超过大小限制时返回对应code的结果
+// GET、HEAD、DELETE请求返回nil；原有的ctx.Data字段改为该方法，见README中的升级说明
+func (self *Context) Data() []byte {
+	data, err := self.ReadData()
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// 获取请求数据（第一次调用时读取），超过大小限制时返回ErrBodyTooLarge
+// GET、HEAD、DELETE请求返回nil
+func (self *Context) ReadData() ([]byte, error) {
+	if !self.dataRead {
+		self.dataRead = true
+		if self.streamed {
+			self.dataErr = NewError(500, "请求数据已通过BodyReader读取")
+		} else if hasBody(self.Req.Method) {
+			self.data, self.dataErr = ioutil.ReadAll(self.BodyReader())
+		}
+	}
+	return self.data, self.dataErr
+}
+
+// 获取请求数据的流式读取器，用于逐步处理大文件等数据（超过大小限制时Read返回ErrBodyTooLarge）
+// 请求数据根据Content-Encoding自动解压（支持gzip、deflate），使用BodyReader后不能再调用Data/ReadData
+func (self *Context) BodyReader() io.Reader {
+	self.streamed = true
+	return self.inflate(&bodyReader{self, self.Req.Body})
+}
+
+// 停止读取请求数据（ServeHTTP在处理器仍在后台执行时返回前调用），不等待正在进行的读取
+// 通过设置连接的读取截止时间中断正在进行的读取，不支持时关闭请求数据
+func (self *Context) closeBody(w http.ResponseWriter) {
+	self.bodyClosed.Store(true)
+	if err := http.NewResponseController(w).SetReadDeadline(time.Now()); err != nil {
+		// net/http的请求数据在读取时加锁，Close可能阻塞到读取返回
+		go self.Req.Body.Close()
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 未配置server.maxinflate及server.maxbody时，解压后请求数据的默认大小限制
+const defaultMaxInflate = 32 << 20
+
+// 不支持的Content-Encoding
+var ErrUnsupportedEncoding = NewError(415, "不支持的请求数据压缩格式")
+
+// 压缩配置
+type compressConfig struct {
+	enabled    bool  // 是否压缩返回结果
+	minSize    int   // 返回结果超过该字节数时才压缩
+	level      int   // 压缩级别
+	maxInflate int64 // 解压后请求数据的大小限制（server.maxinflate），0表示使用请求数据大小限制
+}
+
+// 读取压缩配置
+func newCompressConfig() *compressConfig {
+	conf := &compressConfig{
+		enabled:    strings.ToLower(os.Getenv("server.gzip")) == "true",
+		minSize:    int(envSize("server.gzip.minsize")),
+		level:      gzip.DefaultCompression,
+		maxInflate: envSize("server.maxinflate"),
+	}
+	if os.Getenv("server.gzip.minsize") == "" {
+		conf.minSize = 1024
+	}
+	if s := os.Getenv("server.gzip.level"); s != "" {
+		level, err := strconv.Atoi(s)
+		if err != nil || level < gzip.HuffmanOnly || level > gzip.BestCompression {
+			panic("server.gzip.level配置错误")
+		}
+		conf.level = level
+	}
+	return conf
+}
+
+// 解压请求数据的读取器（第一次读取时根据Content-Encoding创建解压器）
+type inflateReader struct {
+	encoding string
+	src      io.Reader
+	r        io.Reader
+	remain   int64
+	err      error
+}
+
+// 实现io.Reader接口，解压后的数据超过限制时返回ErrBodyTooLarge
+func (self *inflateReader) Read(p []byte) (int, error) {
+	if self.err != nil {
+		return 0, self.err
+	}
+	if self.r == nil {
+		switch self.encoding {
+		case "gzip", "x-gzip":
+			self.r, self.err = gzip.NewReader(self.src)
+		case "deflate":
+			self.r = flate.NewReader(self.src)
+		default:
+			self.err = ErrUnsupportedEncoding
+		}
+		if self.err != nil {
+			if self.err != ErrUnsupportedEncoding {
+				self.err = inflateErr(self.err)
+			}
+			return 0, self.err
+		}
+	}
+
+	if self.remain <= 0 {
+		// 判断是否还有数据
+		var b [1]byte
+		if n, _ := self.r.Read(b[:]); n > 0 {
+			self.err = ErrBodyTooLarge
+			return 0, self.err
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > self.remain {
+		p = p[:self.remain]
+	}
+	n, err := self.r.Read(p)
+	self.remain -= int64(n)
+	if err != nil && err != io.EOF {
+		err = inflateErr(err)
+	}
+	return n, err
+}
+
+// 转换解压时的错误
+func inflateErr(err error) error {
+	if errors.Is(err, ErrBodyTooLarge) {
+		return ErrBodyTooLarge
+	}
+	return badData()
+}
+
+// 根据Content-Encoding包装请求数据读取器
+func (self *Context) inflate(r io.Reader) io.Reader {
+	encoding := strings.ToLower(strings.TrimSpace(self.Req.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" {
+		return r
+	}
+	return &inflateReader{encoding: encoding, src: r, remain: self.maxInflate()}
+}
+
+// 获取解压后请求数据的大小限制：server.maxinflate、请求数据大小限制（路由配置优先于全局配置）、默认值
+func (self *Context) maxInflate() int64 {
+	if n := self.Cosine.compress.maxInflate; n > 0 {
+		return n
+	}
+	if self.maxBody > 0 {
+		return self.maxBody
+	}
+	return defaultMaxInflate
+}
+
+// 根据Accept-Encoding选择返回结果的压缩格式，不压缩时返回空字符串
+func acceptEncoding(r *http.Request) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, q := parseQuality(part)
+		if (name != "gzip" && name != "deflate") || q <= 0 {
+			continue
+		}
+		// 相同权重时优先使用gzip
+		if q > bestQ || (q == bestQ && name == "gzip") {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// 解析带有权重的值，如：gzip;q=0.8
+func parseQuality(s string) (string, float64) {
+	parts := strings.Split(s, ";")
+	name := strings.ToLower(strings.TrimSpace(parts[0]))
+	q := 1.0
+	for _, p := range parts[1:] {
+		p = strings.TrimSpace(p)
+		if strings.HasPrefix(p, "q=") {
+			if f, err := strconv.ParseFloat(p[2:], 64); err == nil {
+				q = f
+			}
+		}
+	}
+	return name, q
+}
+
+// 压缩返回结果，不需要压缩时原样返回
+func (self *Cosine) compressBody(w http.ResponseWriter, r *http.Request, body []byte) []byte {
+	conf := self.compress
+	if !conf.enabled || len(body) < conf.minSize || w.Header().Get("Content-Encoding") != "" {
+		return body
+	}
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	encoding := acceptEncoding(r)
+	if encoding == "" {
+		return body
+	}
+
+	var buf bytes.Buffer
+	var zw io.WriteCloser
+	if encoding == "gzip" {
+		zw, _ = gzip.NewWriterLevel(&buf, conf.level)
+	} else {
+		zw, _ = flate.NewWriter(&buf, conf.level)
+	}
+	zw.Write(body)
+	zw.Close()
+
+	w.Header().Set("Content-Encoding", encoding)
+	return buf.Bytes()
+}
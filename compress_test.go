@@ -0,0 +1,56 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInflateRouteLimit(t *testing.T) {
+	t.Setenv("server.maxbody", "1000")
+	t.Setenv("server.maxbody./small", "50")
+	cos := New()
+	handler := func(ctx *Context) (int, error) {
+		data, err := ctx.ReadData()
+		return len(data), err
+	}
+	cos.POST("/small", handler)
+	cos.POST("/global", handler)
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(bytes.Repeat([]byte("a"), 500))
+	zw.Close()
+	if buf.Len() > 50 {
+		t.Fatalf("compressed size %d", buf.Len())
+	}
+
+	for path, want := range map[string]string{
+		"/small":  `"code":413`,
+		"/global": `"data":500`,
+	} {
+		r := httptest.NewRequest("POST", path, bytes.NewReader(buf.Bytes()))
+		r.Header.Set("Content-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		cos.ServeHTTP(w, r)
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("%s: got %s, want %s", path, w.Body.String(), want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 读取时间配置（如：30s、1m），未配置时返回0
+func envDuration(name string) time.Duration {
+	s := os.Getenv(name)
+	if s == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		panic(name + "配置错误")
+	}
+	return d
+}
+
+// 读取大小配置（如：1024、512KB、10MB），未配置时返回0
+func envSize(name string) int64 {
+	s := strings.ToUpper(strings.TrimSpace(os.Getenv(name)))
+	if s == "" {
+		return 0
+	}
+
+	_unit := int64(1)
+	for suffix, u := range map[string]UNIT{"KB": KB, "MB": MB, "GB": GB, "TB": TB} {
+		if strings.HasSuffix(s, suffix) {
+			s, _unit = strings.TrimSpace(s[:len(s)-len(suffix)]), int64(u)
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		panic(name + "配置错误")
+	}
+	return n * _unit
+}
@@ -15,17 +15,49 @@
 package cosine
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	neturl "net/url"
 	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// Cosine上下文
+// 业务异常接口，处理器返回实现该接口的error时作为业务异常返回
+type coder interface {
+	Code() int
+}
+
+// Cosine上下文，实现了context.Context接口（请求超时或客户端断开连接时取消）
 type Context struct {
 	*Cosine
+	std    context.Context
+	start  time.Time
+	route  string
+	afters []func(f *Finish)
+	// 请求结束后的清理函数
+	cleanups []func()
+	// 处理器在后台执行时，执行完毕后关闭
+	running chan struct{}
+	problem bool // 是否使用RFC 7807错误格式
+	mu      sync.Mutex
+
+	// 请求数据
+	bodyClosed atomic.Bool // 停止读取请求数据
+	maxBody    int64       // 请求数据大小限制（路由配置优先于全局配置）
+	data       []byte
+	dataErr    error
+	dataRead   bool
+	streamed   bool
+	multipart  *multipartForm
+
 	params map[string]interface{}
-	injts  map[reflect.Type]reflect.Value
-	Data   []byte
+	injts  injector
+	query  neturl.Values
+	form   neturl.Values
 	Req    *http.Request
 	Res    *Response
 }
@@ -35,63 +67,188 @@ func (self *Context) Param(name string) interface{} {
 	return self.params[name]
 }
 
-// 获取url中的参数转换成string
+// 获取url中的参数转换成string（参数不存在时返回空字符串，需要区分时使用PathParams）
 func (self *Context) ParamToString(name string) string {
-	if self.Param(name) != nil {
-		return self.Param(name).(string)
-	}
-	return ""
+	s, _ := self.PathParams().String(name)
+	return s
 }
 
-// 获取url中的参数转换成int
+// 获取url中的参数转换成int（参数不存在或格式错误时返回0，需要区分时使用PathParams）
 func (self *Context) ParamToInt(name string) int {
-	if self.Param(name) != nil {
-		return self.Param(name).(int)
-	}
-	return 0
+	n, _ := self.PathParams().Int(name)
+	return n
 }
 
-// 获取url中的参数转换成int64
+// 获取url中的参数转换成int64（参数不存在或格式错误时返回0，需要区分时使用PathParams）
 func (self *Context) ParamToInt64(name string) int64 {
-	if self.Param(name) != nil {
-		return self.Param(name).(int64)
-	}
-	return 0
+	n, _ := self.PathParams().Int64(name)
+	return n
 }
 
-// 获取url中的参数转换成float32
+// 获取url中的参数转换成float32（参数不存在或格式错误时返回0，需要区分时使用PathParams）
 func (self *Context) ParamToFloat32(name string) float32 {
-	if self.Param(name) != nil {
-		return self.Param(name).(float32)
-	}
-	return 0.0
+	f, _ := self.PathParams().Float64(name)
+	return float32(f)
 }
 
-// 获取url中的参数转换成float64
+// 获取url中的参数转换成float64（参数不存在或格式错误时返回0，需要区分时使用PathParams）
 func (self *Context) ParamToFloat64(name string) float64 {
-	if self.Param(name) != nil {
-		return self.Param(name).(float64)
-	}
-	return 0.0
+	f, _ := self.PathParams().Float64(name)
+	return f
 }
 
 // 映射中间件实例
 func (self *Context) Map(v interface{}) {
+	if self.injts == nil {
+		self.injts = make(injector)
+	}
 	self.injts[reflect.TypeOf(v)] = reflect.ValueOf(v)
 }
 
-// 将提交的数据转换成JSON
+// 按接口类型映射中间件实例，ifacePtr为接口指针，如：ctx.MapTo(store, (*UserStore)(nil))
+func (self *Context) MapTo(v interface{}, ifacePtr interface{}) {
+	if self.injts == nil {
+		self.injts = make(injector)
+	}
+	self.injts.mapTo(v, ifacePtr)
+}
+
+// 将提交的数据转换成JSON，并根据validate标签校验（解析或校验失败时返回code为400的结果）
+// 需要自行处理错误时使用ReadJSON
 func (self *Context) DataToJSON(v interface{}) {
-	if self.Req.Method != "GET" && self.Req.Method != "HEAD" && self.Req.Method != "DELETE" {
-		err := json.Unmarshal(self.Data, v)
-		if err != nil {
-			panic(err)
+	if err := self.ReadJSON(v); err != nil {
+		panic(err)
+	}
+}
+
+// 处理处理器的返回值，返回false时终止执行后续处理器
+func (self *Context) result(out []reflect.Value) bool {
+	var data, err reflect.Value
+	switch len(out) {
+	case 1:
+		if out[0].Type().Implements(errorType) {
+			err = out[0]
+		} else {
+			data = out[0]
+		}
+	case 2:
+		data, err = out[0], out[1]
+	default:
+		return true
+	}
+
+	// 返回错误
+	if e := toError(err); e != nil {
+		self.errorWrapper(e)
+		return false
+	}
+
+	// 只返回error且为nil
+	if !data.IsValid() {
+		self.success()
+		return true
+	}
+
+	// 返回数据（已通过Text、HTML、File、Redirect等设置非JSON的返回内容时忽略返回的数据）
+	if self.Res.content == nil {
+		self.Res.DataWrapper(data.Interface())
+	}
+	return true
+}
+
+// 处理器返回的error为nil且没有设置返回结果时，作为成功的返回结果（code为200，data为null）
+func (self *Context) success() {
+	if self.Res.Code == 0 && self.Res.content == nil {
+		self.Res.DataWrapper(nil)
+	}
+}
+
+// 将返回值转换成error，未返回错误（包括类型化的nil，如：(*Error)(nil)）时返回nil
+func toError(v reflect.Value) error {
+	if !v.IsValid() {
+		return nil
+	}
+	e := v
+	if e.Kind() == reflect.Interface {
+		if e.IsNil() {
+			return nil
+		}
+		e = e.Elem()
+	}
+	switch e.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		if e.IsNil() {
+			return nil
+		}
+	}
+	return v.Interface().(error)
+}
+
+// 将错误转换成返回结果：*Error及实现了Code() int的error作为业务异常返回，其余作为服务器内部错误返回
+func (self *Context) errorWrapper(err error) {
+	var e *Error
+	if errors.As(err, &e) {
+		// 内部原因只记录日志
+		if e.cause != nil {
+			self.logger.Error(self.Req.Method + " - " + self.Req.URL.Path + " - " + err.Error())
 		}
+		self.Res.errorWrapper(e)
+		return
+	}
+	if e, ok := err.(coder); ok {
+		self.Res.ExceptionWrapper(e.Code(), err.Error())
+		return
 	}
 
+	self.logger.Error(self.Req.Method + " - " + self.Req.URL.Path + " - " + err.Error())
+	self.Res.ErrorWrapper()
 }
 
-// 获取中间件实例
-func (self *Context) getVal(t reflect.Type) reflect.Value {
-	return self.injts[t]
+// 依次执行处理器，返回false表示处理器返回了错误或请求已超时/取消
+func (self *Context) run(handlers []*handler) bool {
+	for _, h := range handlers {
+		if self.Err() != nil || !h.invoke(self) {
+			return false
+		}
+	}
+	return true
+}
+
+// 获取中间件实例，查找顺序：内置对象（*Context、context.Context和*Logger）、请求中映射的对象、全局对象、提供者
+// 接口类型的参数在没有通过MapTo映射时，使用唯一实现了该接口的实例
+func (self *Context) getVal(t reflect.Type) (reflect.Value, error) {
+	switch t {
+	case contextType, stdContextType:
+		return reflect.ValueOf(self), nil
+	case loggerType:
+		return reflect.ValueOf(self.logger), nil
+	}
+	if v, ok := self.injts[t]; ok {
+		return v, nil
+	}
+	if v, ok := self.Cosine.injts[t]; ok {
+		return v, nil
+	}
+
+	// 延迟执行提供者，结果在本次请求中缓存
+	if p, ok := self.Cosine.providers[t]; ok {
+		v, err := p.call(self)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if self.injts == nil {
+			self.injts = make(injector)
+		}
+		self.injts[t] = v
+		return v, nil
+	}
+
+	if t.Kind() == reflect.Interface {
+		typ, err := unique(t, self.injts.assignable(t), self.Cosine.injts.assignable(t), self.Cosine.providers.assignable(t))
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return self.getVal(typ)
+	}
+	return reflect.Value{}, fmt.Errorf("找不到类型为%s的注入对象", t)
 }
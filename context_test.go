@@ -0,0 +1,95 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// 执行请求并返回输出的内容
+func serve(cos *Cosine, method, path string) string {
+	w := httptest.NewRecorder()
+	cos.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w.Body.String()
+}
+
+func TestResultErrorTypes(t *testing.T) {
+	cos := New()
+	cos.GET("/error", func() *Error {
+		return NewError(10001, "bad")
+	})
+	cos.GET("/nil-error", func() *Error {
+		return nil
+	})
+	cos.GET("/typed-nil", func() error {
+		var e *Error
+		return e
+	})
+	cos.GET("/nil", func() error {
+		return nil
+	})
+	cos.GET("/ctx-nil", func(ctx *Context) error {
+		return nil
+	})
+	cos.GET("/forbidden", func(ctx *Context) error {
+		ctx.Res.ForbiddenWrapper()
+		return nil
+	})
+	cos.GET("/pair", func() (string, *Error) {
+		return "", NewError(10002, "bad")
+	})
+	cos.GET("/pair-nil", func() (string, *Error) {
+		return "ok", nil
+	})
+
+	for path, want := range map[string]string{
+		"/error":     `{"code":10001,"message":"bad","data":null}`,
+		"/nil-error": `{"code":200,"message":"","data":null}`,
+		"/typed-nil": `{"code":200,"message":"","data":null}`,
+		"/nil":       `{"code":200,"message":"","data":null}`,
+		"/ctx-nil":   `{"code":200,"message":"","data":null}`,
+		"/forbidden": `{"code":403,"message":"API访问权限不足","data":null}`,
+		"/pair":      `{"code":10002,"message":"bad","data":null}`,
+		"/pair-nil":  `{"code":200,"message":"","data":"ok"}`,
+	} {
+		if got := serve(cos, "GET", path); got != want {
+			t.Errorf("%s: got %s, want %s", path, got, want)
+		}
+	}
+}
+
+func TestNilErrorStatus(t *testing.T) {
+	cos := New()
+	cos.GET("/created", func(ctx *Context) error {
+		ctx.Res.SetStatus(201)
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	cos.ServeHTTP(w, httptest.NewRequest("GET", "/created", nil))
+	if w.Code != 201 || w.Body.String() != `{"code":200,"message":"","data":null}` {
+		t.Fatalf("got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestChkHandlerSecondReturn(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	chkHandler(func() (string, string) { return "", "" })
+}
@@ -16,14 +16,19 @@ package cosine
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
+	"html/template"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"os"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 const _VERSION = "1.0.0708"
@@ -36,17 +41,24 @@ func Version() string {
 // 处理器
 type Handler interface{}
 
-// 初始化
-func init() {
-	// 获取参数
-	configPath := flag.String("config", "config.ini", "配置文件路径")
-	flag.Parse()
+// 配置文件路径
+var (
+	configPath = flag.String("config", "config.ini", "配置文件路径")
+	configOnce sync.Once
+)
+
+// 读取配置文件（第一次调用New时读取，未解析命令行参数时先解析）
+func loadConfig() {
+	if !flag.Parsed() {
+		flag.Parse()
+	}
 
 	// 配置文件读取
 	fp, err := os.Open(*configPath)
 	if err != nil {
 		panic(err)
 	}
+	defer fp.Close()
 	reader := bufio.NewReader(fp)
 
 	// 循环读取ini文件中的数据
@@ -86,25 +98,66 @@ func init() {
 	}
 }
 
+// error类型
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
 // 校验处理器
 func chkHandler(h Handler) {
-	if reflect.TypeOf(h).Kind() != reflect.Func {
+	t := reflect.TypeOf(h)
+	if t == nil || t.Kind() != reflect.Func {
 		panic("Cosine要求所有处理器必须是一个函数")
 	}
+
+	// 处理器返回值只能是：无返回值、(T)、(error)、(T, error)，error可以是实现了error接口的类型（如：*Error）
+	switch t.NumOut() {
+	case 0, 1:
+	case 2:
+		if !t.Out(1).Implements(errorType) {
+			panic("Cosine要求处理器返回两个值时第二个返回值必须实现error接口")
+		}
+	default:
+		panic("Cosine要求处理器最多只能有两个返回值")
+	}
 }
 
 // Cosine结构体
 type Cosine struct {
 	*Router
-	logger   *Logger
-	handlers []Handler
+	logger    *Logger
+	handlers  []*handler
+	injts     injector
+	providers providers
+	declared  injector
+
+	panicHandlers []PanicHandler
+	timeout       time.Duration
+	maxBody       int64
+	upload        *uploadConfig
+	page          *pageConfig
+	compress      *compressConfig
+	jsonOptions   []JSONOption
+	status        *statusConfig
+	envelope      Envelope
+	templates     *template.Template
 }
 
 // 获取Cosine实例
 func New() *Cosine {
+	configOnce.Do(loadConfig)
+
 	// 初始化Cosine
 	cos := &Cosine{
-		logger: newLogger(),
+		logger:    newLogger(),
+		injts:     make(injector),
+		providers: make(providers),
+		declared:  make(injector),
+		timeout:   envDuration("server.timeout"),
+		maxBody:   envSize("server.maxbody"),
+		upload:    newUploadConfig(),
+		page:      newPageConfig(),
+		compress:  newCompressConfig(),
+		status:    newStatusConfig(),
+		templates: loadTemplates(os.Getenv("template.dir")),
 		Router: &Router{
 			urls: make(map[string][]*url),
 		},
@@ -115,6 +168,7 @@ func New() *Cosine {
 
 // 实现http.Handler接口
 func (self *Cosine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
 	if self.logger.GetLevel() <= DEBUG {
 		self.logger.Debug(r.RemoteAddr + " - " + r.Method + " - " + r.RequestURI)
 	}
@@ -127,69 +181,182 @@ func (self *Cosine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 设置返回参数
-	w.Header().Set("Content-Type", "application/json;charset=utf-8")
-
 	// 实例化Context
 	ctx := &Context{
 		Cosine: self,
-		injts:  make(map[reflect.Type]reflect.Value),
+		std:    r.Context(),
+		start:  start,
 		Req:    r,
-		Res:    new(Response),
+		Res:    &Response{templates: self.templates},
 	}
+	defer ctx.cleanup()
 
-	// 获取request body中的数据
-	if r.Method != "GET" && r.Method != "HEAD" && r.Method != "DELETE" {
-		defer r.Body.Close()
-		ctx.Data, _ = ioutil.ReadAll(r.Body)
-	}
+	// 在路由匹配前确定错误格式，找不到接口及中间件的错误也可使用RFC 7807格式
+	ctx.problem = acceptsProblem(r.Header.Get("Accept"))
 
-	// 将Context添加为内置对象
-	ctx.Map(ctx)
-	ctx.Map(self.logger)
+	// 返回结果（处理超时时不再使用ctx.Res，处理器可能仍在后台修改）
+	out := ctx.Res
 
 	// 匹配请求对应的处理器
-	if handlers, vars, ok := self.Router.match(r.Method, path); ok {
+	if u, vars, ok := self.Router.match(r.Method, path); ok {
 		// url中的参数
 		ctx.params = vars
+		ctx.route = u.path
+		ctx.problem = ctx.problem || u.problem
 
-		// 添加全局handlers
-		handlers = append(self.handlers, handlers...)
-		// 依次执行handlers
-		for _, handler := range handlers {
-			h := reflect.ValueOf(handler)
-			if h.Kind() == reflect.Func {
-				// 获取handler参数数量
-				num := h.Type().NumIn()
-
-				// 依赖注入参数
-				params := make([]reflect.Value, num)
-				for i := 0; i < num; i++ {
-					params[i] = ctx.getVal(h.Type().In(i))
-				}
+		// 限制请求数据大小，路由配置优先于全局配置
+		maxBody := self.maxBody
+		if u.maxBody > 0 {
+			maxBody = u.maxBody
+		}
+		ctx.maxBody = maxBody
+		limited := maxBody > 0 && hasBody(r.Method)
+		if limited {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+		}
+
+		// 路由超时时间优先于全局超时时间
+		timeout := self.timeout
+		if u.timeout > 0 {
+			timeout = u.timeout
+		}
 
-				// 执行handle
-				h.Call(params)
+		// 执行handlers
+		if limited && r.ContentLength > maxBody {
+			// 声明的数据大小已超过限制，无需执行handlers
+			out.TooLargeWrapper()
+		} else if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx.std, cancel = context.WithTimeout(ctx.std, timeout)
+			defer cancel()
+
+			if !ctx.serveTimeout(u.handlers) {
+				// 处理器仍在后台执行，停止读取请求数据
+				ctx.closeBody(w)
+				if ctx.Err() == context.Canceled {
+					// 客户端已断开连接，无需返回（处理器仍可能修改ctx.Res，回调中使用空的返回结果）
+					self.logger.Warn("客户端断开连接 - " + r.Method + " - " + path)
+					ctx.finish(&Finish{Response: new(Response)})
+					return
+				}
+				self.logger.Warn("请求处理超时 - " + r.Method + " - " + path)
+				out = new(Response)
+				out.TimeoutWrapper()
 			}
+		} else {
+			ctx.serve(u.handlers)
 		}
 	} else {
 		// 找不到接口
-		ctx.Res.NotFoundWrapper()
+		out.NotFoundWrapper()
 	}
 
 	// 输出
-	res, _ := json.Marshal(ctx.Res)
-	w.Write(res)
+	out.writeHeader(w)
+	if out.content != nil {
+		status, n := ctx.writeContent(w, r, out, self.httpStatus(out))
+		ctx.finish(&Finish{Response: out, Status: status, Bytes: n})
+		return
+	}
+
+	// 根据Accept选择返回数据格式
+	enc, ok := negotiate(r.Header.Get("Accept"))
+	if !ok {
+		// 客户端无法解析返回结果中的code，始终返回HTTP状态码406
+		out.NotAcceptableWrapper()
+		out.Status = http.StatusNotAcceptable
+		enc = &encoder{jsonContentType, json.Marshal}
+	}
+	w.Header().Add("Vary", "Accept")
+	out.writeLinks(w, r)
+	status, res, contentType := ctx.render(out, enc)
+	if bodyless(status) {
+		w.WriteHeader(status)
+		ctx.finish(&Finish{Response: out, Status: status})
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	res = self.compressBody(w, r, res)
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+	}
+	n, _ := w.Write(res)
+
+	// 执行请求完成后的回调
+	ctx.finish(&Finish{Response: out, Status: status, Bytes: n})
 }
 
 // 添加中间件
 func (self *Cosine) Use(h Handler) {
-	chkHandler(h)
-	self.handlers = append(self.handlers, h)
+	self.handlers = append(self.handlers, compile(h))
+}
+
+// 映射全局对象，所有请求共享同一个实例
+func (self *Cosine) Map(v interface{}) {
+	self.injts[reflect.TypeOf(v)] = reflect.ValueOf(v)
+}
+
+// 按接口类型映射全局对象，ifacePtr为接口指针，如：cos.MapTo(store, (*UserStore)(nil))
+func (self *Cosine) MapTo(v interface{}, ifacePtr interface{}) {
+	self.injts.mapTo(v, ifacePtr)
+}
+
+// 添加请求级别的对象提供者，fn的格式为func(*Context) (T, error)或func(*Context) T
+// 只有处理器需要类型T的参数时才会执行，执行结果在本次请求中缓存
+func (self *Cosine) Provide(fn interface{}) {
+	self.providers.add(fn)
+}
+
+// 声明由中间件在请求中映射（ctx.Map/ctx.MapTo）的类型，用于Verify校验
+// 如：cos.Declare((*Session)(nil))；接口类型使用接口指针，如：cos.Declare((*UserStore)(nil))
+func (self *Cosine) Declare(vs ...interface{}) {
+	for _, v := range vs {
+		t := reflect.TypeOf(v)
+		if t == nil {
+			panic("Cosine要求Declare的参数不能是nil")
+		}
+		if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Interface {
+			t = t.Elem()
+		}
+		self.declared[t] = reflect.Value{}
+	}
+}
+
+// 校验所有处理器的参数是否都可以注入
+// Run时会自动校验，校验失败时无法启动（中间件通过ctx.Map映射但未使用Declare声明的类型也会校验失败）
+func (self *Cosine) Verify() error {
+	errs := self.verify("全局中间件", self.handlers)
+
+	methods := make([]string, 0, len(self.urls))
+	for method := range self.urls {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	for _, method := range methods {
+		for _, u := range self.urls[method] {
+			errs = append(errs, self.verify(method+" "+u.path, u.handlers)...)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New("处理器参数无法注入：\n" + strings.Join(errs, "\n"))
+	}
+	return nil
 }
 
 // 运行Cosine
 func (self *Cosine) Run() {
+	// 启动前校验处理器参数，校验失败时无法启动（server.verify=false时只记录警告）
+	if err := self.Verify(); err != nil {
+		if strings.ToLower(os.Getenv("server.verify")) != "false" {
+			panic(err.Error() + "\n（中间件在请求中映射的类型可使用cos.Declare声明）")
+		}
+		if self.logger.GetLevel() <= WARN {
+			self.logger.Warn(err.Error() + "\n（中间件在请求中映射的类型可使用cos.Declare声明）")
+		}
+	}
+
 	var err error
 	switch os.Getenv("server.protocol") {
 	case "http":
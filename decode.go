@@ -0,0 +1,143 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"encoding/xml"
+	"mime"
+	"strings"
+	"sync"
+)
+
+// 不支持的请求数据格式
+var ErrUnsupportedMediaType = NewError(415, "不支持的请求数据格式")
+
+// 请求数据解析器，将请求数据解析到v中（v为结构体指针）
+type Decoder func(ctx *Context, v interface{}) error
+
+// 请求数据解析器（按Content-Type索引）
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[string]Decoder{
+		"application/json":                  decodeJSON,
+		"application/x-www-form-urlencoded": decodeForm,
+		"multipart/form-data":               decodeMultipart,
+		"application/xml":                   decodeXML,
+		"text/xml":                          decodeXML,
+		"application/msgpack":               decodeMsgpack,
+		"application/x-msgpack":             decodeMsgpack,
+	}
+)
+
+// 注册自定义请求数据解析器，如：RegisterDecoder("application/yaml", decodeYAML)
+func RegisterDecoder(contentType string, d Decoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[strings.ToLower(contentType)] = d
+}
+
+// 根据Content-Type解析请求数据并根据validate标签校验
+// 未设置Content-Type时按JSON解析，不支持的格式返回ErrUnsupportedMediaType，解析或校验失败时返回code为400的*Error
+func (self *Context) Bind(v interface{}) error {
+	d, err := self.decoder()
+	if err != nil {
+		return err
+	}
+	if err = d(self, v); err != nil {
+		return err
+	}
+	return Validate(v)
+}
+
+// 获取请求对应的解析器
+func (self *Context) decoder() (Decoder, error) {
+	ct := self.Req.Header.Get("Content-Type")
+	if ct == "" {
+		return decodeJSON, nil
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	decodersMu.RLock()
+	d, ok := decoders[mt]
+	decodersMu.RUnlock()
+	if ok {
+		return d, nil
+	}
+	if strings.HasSuffix(mt, "+json") {
+		return decodeJSON, nil
+	}
+	if strings.HasSuffix(mt, "+xml") {
+		return decodeXML, nil
+	}
+	return nil, ErrUnsupportedMediaType
+}
+
+// 请求数据格式错误
+func badData() error {
+	return NewError(400, "请求数据格式错误")
+}
+
+// 解析JSON（使用全局JSON解析选项）
+func decodeJSON(ctx *Context, v interface{}) error {
+	return ctx.unmarshalJSON(v)
+}
+
+// 解析XML
+func decodeXML(ctx *Context, v interface{}) error {
+	data, err := ctx.ReadData()
+	if err != nil || len(data) == 0 {
+		return err
+	}
+	if err = xml.Unmarshal(data, v); err != nil {
+		return badData()
+	}
+	return nil
+}
+
+// 解析MessagePack
+func decodeMsgpack(ctx *Context, v interface{}) error {
+	data, err := ctx.ReadData()
+	if err != nil || len(data) == 0 {
+		return err
+	}
+	if err = unmarshalMsgpack(data, v); err != nil {
+		return badData()
+	}
+	return nil
+}
+
+// 解析application/x-www-form-urlencoded表单（使用form标签）
+func decodeForm(ctx *Context, v interface{}) error {
+	if _, err := ctx.ReadData(); err != nil {
+		return err
+	}
+	return bindTagged(v, "form", func(name string) []string {
+		return ctx.formValues()[name]
+	})
+}
+
+// 解析multipart/form-data表单中的普通字段（使用form标签）
+func decodeMultipart(ctx *Context, v interface{}) error {
+	form, err := ctx.multipartForm()
+	if err != nil {
+		return err
+	}
+	return bindTagged(v, "form", func(name string) []string {
+		return form.values[name]
+	})
+}
@@ -0,0 +1,223 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 默认的返回数据格式
+const jsonContentType = "application/json;charset=utf-8"
+
+// 返回结果序列化器
+type Encoder func(v interface{}) ([]byte, error)
+
+// 返回结果序列化器及对应的Content-Type
+type encoder struct {
+	contentType string
+	encode      Encoder
+}
+
+// 返回结果序列化器（按媒体类型索引）
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[string]*encoder{
+		"application/json":      {jsonContentType, json.Marshal},
+		"application/xml":       {"application/xml;charset=utf-8", marshalXML},
+		"text/xml":              {"text/xml;charset=utf-8", marshalXML},
+		"application/msgpack":   {"application/msgpack", marshalMsgpack},
+		"application/x-msgpack": {"application/x-msgpack", marshalMsgpack},
+	}
+)
+
+// 注册自定义返回结果序列化器，如：RegisterEncoder("application/yaml", yaml.Marshal)
+// contentType可以带有参数，如：application/yaml;charset=utf-8
+func RegisterEncoder(contentType string, e Encoder) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		panic("Cosine序列化器的Content-Type错误：" + contentType)
+	}
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders[mt] = &encoder{contentType, e}
+}
+
+// 根据Accept选择返回结果的序列化器，按权重从高到低依次匹配（相同权重时具体的类型优先于通配符）：
+// 已注册的媒体类型、type/*（匹配已注册的同类媒体类型，application/*使用JSON）、
+// +json后缀的类型（如：application/problem+json，使用JSON）、*/*（使用JSON）
+// 未设置Accept时使用JSON，没有可接受的格式时返回false
+func negotiate(accept string) (*encoder, bool) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	def := encoders["application/json"]
+	if strings.TrimSpace(accept) == "" {
+		return def, true
+	}
+
+	type accepted struct {
+		mt string
+		q  float64
+	}
+	var list []accepted
+	for _, part := range strings.Split(accept, ",") {
+		if mt, q := parseQuality(part); mt != "" && q > 0 {
+			list = append(list, accepted{mt, q})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].q != list[j].q {
+			return list[i].q > list[j].q
+		}
+		return specificity(list[i].mt) > specificity(list[j].mt)
+	})
+
+	for _, a := range list {
+		if e := matchEncoder(a.mt, def); e != nil {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// 媒体类型的具体程度：*/*为0，type/*为1，其余为2
+func specificity(mt string) int {
+	switch {
+	case mt == "*/*":
+		return 0
+	case strings.HasSuffix(mt, "/*"):
+		return 1
+	}
+	return 2
+}
+
+// 查找媒体类型对应的序列化器，找不到时返回nil（调用时已持有encodersMu）
+func matchEncoder(mt string, def *encoder) *encoder {
+	if mt == "*/*" || strings.HasSuffix(mt, "+json") {
+		return def
+	}
+	if e, ok := encoders[mt]; ok {
+		return e
+	}
+	if !strings.HasSuffix(mt, "/*") {
+		return nil
+	}
+
+	// type/*：优先使用JSON，其次按媒体类型排序后的第一个
+	prefix := strings.TrimSuffix(mt, "*")
+	if strings.HasPrefix("application/json", prefix) {
+		return def
+	}
+	var names []string
+	for name := range encoders {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	return encoders[names[0]]
+}
+
+// 转换成通用结构（按JSON规则，因此支持json标签及自定义封装格式）
+func toGeneric(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var val interface{}
+	err = dec.Decode(&val)
+	return val, err
+}
+
+// 序列化成XML，根元素为response：对象的字段作为子元素（名称不合法时使用<item key="...">），数组的元素为<item>
+func marshalXML(v interface{}) ([]byte, error) {
+	val, err := toGeneric(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	writeXML(&buf, "response", "", val)
+	return buf.Bytes(), nil
+}
+
+// 写入XML元素
+func writeXML(buf *bytes.Buffer, name, key string, v interface{}) {
+	buf.WriteString("<" + name)
+	if key != "" {
+		buf.WriteString(` key="`)
+		xml.EscapeText(buf, []byte(key))
+		buf.WriteString(`"`)
+	}
+	buf.WriteString(">")
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if isXMLName(k) {
+				writeXML(buf, k, "", val[k])
+			} else {
+				writeXML(buf, "item", k, val[k])
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			writeXML(buf, "item", "", item)
+		}
+	case string:
+		xml.EscapeText(buf, []byte(val))
+	case json.Number:
+		buf.WriteString(val.String())
+	case bool:
+		if val {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	}
+
+	buf.WriteString("</" + name + ">")
+}
+
+// 判断是否可以作为XML元素名称
+func isXMLName(s string) bool {
+	if s == "" || strings.HasPrefix(strings.ToLower(s), "xml") {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c > 0x7f:
+		case i > 0 && (c == '-' || c == '.' || c >= '0' && c <= '9'):
+		default:
+			return false
+		}
+	}
+	return true
+}
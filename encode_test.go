@@ -0,0 +1,63 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	for accept, want := range map[string]string{
+		"":                                 jsonContentType,
+		"*/*":                              jsonContentType,
+		"application/xml":                  "application/xml;charset=utf-8",
+		"application/msgpack":              "application/msgpack",
+		"application/json;q=0.5, text/xml": "text/xml;charset=utf-8",
+		"text/csv, */*;q=0.1":              jsonContentType,
+		"application/vnd.api+json":         jsonContentType,
+		"application/problem+json":         jsonContentType,
+		"application/*":                    jsonContentType,
+		"text/*":                           "text/xml;charset=utf-8",
+		"*/*, application/xml":             "application/xml;charset=utf-8",
+		"*/*;q=0.5, application/*;q=0.8":   jsonContentType,
+		"text/csv, application/xml;q=0.9, */*;q=0.8":                      "application/xml;charset=utf-8",
+		"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8": "application/xml;charset=utf-8",
+	} {
+		e, ok := negotiate(accept)
+		if !ok || e.contentType != want {
+			t.Errorf("%q: got %v %v, want %s", accept, e, ok, want)
+		}
+	}
+
+	for _, accept := range []string{"text/csv", "application/xhtml+xml", "image/*", "*/*;q=0"} {
+		if e, ok := negotiate(accept); ok {
+			t.Errorf("%q: got %s, want not acceptable", accept, e.contentType)
+		}
+	}
+}
+
+func TestNegotiateProblem(t *testing.T) {
+	cos := New()
+	cos.GET("/problem", ProblemResponse, func() error { return NewError(10001, "bad") })
+
+	r := httptest.NewRequest("GET", "/problem", nil)
+	r.Header.Set("Accept", "application/problem+json")
+	w := httptest.NewRecorder()
+	cos.ServeHTTP(w, r)
+	if ct := w.Header().Get("Content-Type"); ct != problemContentType {
+		t.Fatalf("got %s %s", ct, w.Body.String())
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import "encoding/json"
+
+// 返回结果封装函数，返回值将被序列化后输出
+type Envelope func(res *Response) interface{}
+
+// 设置返回结果的封装格式（默认输出{code,message,data}），如：
+//
+//	cos.SetEnvelope(func(res *cosine.Response) interface{} {
+//		return map[string]interface{}{"status": res.Code, "result": res.Data}
+//	})
+func (self *Cosine) SetEnvelope(fn Envelope) {
+	self.envelope = fn
+}
+
+// 路由级别的原样输出：正确的返回结果只输出数据本身，不封装
+//
+//	cos.POST("/notify", cosine.RawResponse, func(ctx *cosine.Context) interface{} {...})
+func RawResponse(ctx *Context) {
+	ctx.Res.raw = true
+}
+
+// 序列化返回结果，返回HTTP状态码、序列化后的数据及Content-Type（不允许有返回内容时数据为nil）
+// 自定义封装格式或序列化器发生panic时与处理器相同，记录日志并按服务器内部错误返回
+func (self *Context) render(out *Response, enc *encoder) (status int, res []byte, contentType string) {
+	defer func() {
+		if err := recover(); err != nil {
+			self.recoverTo(out, err)
+			status, res, contentType = self.renderDefault(out)
+		}
+	}()
+
+	status, body := self.httpStatus(out), self.wrap(out)
+	if self.isProblem(out) {
+		status, body = self.toProblem(out)
+		enc = &encoder{problemContentType, json.Marshal}
+	}
+	if bodyless(status) {
+		return status, nil, ""
+	}
+
+	res, err := enc.encode(body)
+	if err != nil {
+		self.logger.Error("返回结果序列化失败 - " + self.Req.Method + " - " + self.Req.URL.Path + " - " + err.Error())
+		out.ErrorWrapper()
+		return self.renderDefault(out)
+	}
+	return status, res, enc.contentType
+}
+
+// 不使用自定义封装格式及序列化器，按JSON序列化返回结果
+func (self *Context) renderDefault(out *Response) (int, []byte, string) {
+	if self.isProblem(out) {
+		status, p := self.toProblem(out)
+		res, _ := json.Marshal(p)
+		return status, res, problemContentType
+	}
+	res, _ := json.Marshal(out)
+	return self.httpStatus(out), res, jsonContentType
+}
+
+// 获取需要序列化的返回结果
+// 原样输出且code为200时只输出数据本身，否则使用SetEnvelope设置的封装格式
+func (self *Cosine) wrap(out *Response) interface{} {
+	if out.raw && out.Code == 200 {
+		return out.Data
+	}
+	if self.envelope != nil {
+		return self.envelope(out)
+	}
+	return out
+}
@@ -0,0 +1,96 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type panicReader struct{}
+
+func (panicReader) Read(p []byte) (int, error) {
+	panic("read")
+}
+
+func TestEnvelope(t *testing.T) {
+	cos := New()
+	cos.SetEnvelope(func(res *Response) interface{} {
+		return map[string]interface{}{"status": res.Code, "result": res.Data}
+	})
+	cos.GET("/data", func() int { return 1 })
+	cos.GET("/raw", func(ctx *Context) { ctx.Res.RawWrapper("ok") })
+	cos.GET("/route-raw", RawResponse, func() string { return "SUCCESS" })
+
+	for path, want := range map[string]string{
+		"/data":      `{"result":1,"status":200}`,
+		"/raw":       `"ok"`,
+		"/route-raw": `"SUCCESS"`,
+	} {
+		if got := serve(cos, "GET", path); got != want {
+			t.Errorf("%s: got %s, want %s", path, got, want)
+		}
+	}
+}
+
+func TestOutputPanic(t *testing.T) {
+	cos := New()
+	var panics int
+	cos.OnPanic(func(ctx *Context, p *Panic) {
+		panics++
+	})
+	finished := 0
+	cos.Use(func(ctx *Context) {
+		ctx.After(func(f *Finish) {
+			finished++
+		})
+	})
+	cos.SetEnvelope(func(res *Response) interface{} {
+		if res.Data == "envelope" {
+			panic("envelope")
+		}
+		return res
+	})
+	RegisterEncoder("application/x-panic", func(v interface{}) ([]byte, error) {
+		panic("encoder")
+	})
+	RegisterEncoder("application/x-fail", func(v interface{}) ([]byte, error) {
+		return nil, errors.New("fail")
+	})
+	cos.GET("/envelope", func() string { return "envelope" })
+	cos.GET("/encoder", func() string { return "encoder" })
+	cos.GET("/stream", func(ctx *Context) { ctx.Res.Stream("text/plain", io.MultiReader(panicReader{})) })
+
+	for _, c := range []struct{ path, accept string }{
+		{"/envelope", ""},
+		{"/encoder", "application/x-panic"},
+		{"/encoder", "application/x-fail"},
+		{"/stream", ""},
+	} {
+		r := httptest.NewRequest("GET", c.path, nil)
+		r.Header.Set("Accept", c.accept)
+		w := httptest.NewRecorder()
+		cos.ServeHTTP(w, r)
+		if !strings.Contains(w.Body.String(), `"code":500`) {
+			t.Errorf("%s %s: got %s", c.path, c.accept, w.Body.String())
+		}
+	}
+	if panics != 3 || finished != 4 {
+		t.Fatalf("panics %d, finished %d", panics, finished)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+// Cosine业务异常，处理器返回或panic时转换成对应的返回结果
+type Error struct {
+	Code    int         // 返回结果中的code
+	Message string      // 返回结果中的message
+	Status  int         // HTTP状态码，0表示使用默认值
+	Details interface{} // 返回结果中的details（可选）
+	cause   error       // 内部原因，只记录日志，不返回给客户端
+}
+
+// 创建业务异常
+func NewError(code int, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// 使用业务异常包装内部错误，err只记录日志，不返回给客户端
+func Wrap(err error, code int, message string) *Error {
+	return &Error{Code: code, Message: message, cause: err}
+}
+
+// 实现error接口
+func (self *Error) Error() string {
+	if self.cause != nil {
+		return self.Message + ": " + self.cause.Error()
+	}
+	return self.Message
+}
+
+// 获取内部原因
+func (self *Error) Unwrap() error {
+	return self.cause
+}
+
+// 设置HTTP状态码（返回新的Error，不修改原对象）
+func (self *Error) WithStatus(status int) *Error {
+	e := *self
+	e.Status = status
+	return &e
+}
+
+// 设置详细信息（返回新的Error，不修改原对象）
+func (self *Error) WithDetails(details interface{}) *Error {
+	e := *self
+	e.Details = details
+	return &e
+}
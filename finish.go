@@ -0,0 +1,106 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"fmt"
+	"time"
+)
+
+// 请求完成信息
+type Finish struct {
+	Method   string        // 请求方法
+	Route    string        // 匹配的路由（如：/v1/user/:id），找不到接口时为空
+	Response *Response     // 最终输出的返回结果，客户端断开连接未输出时为空的返回结果
+	Status   int           // HTTP状态码，客户端断开连接未输出时为0
+	Bytes    int           // 输出的字节数
+	Duration time.Duration // 请求处理时间（包含输出）
+}
+
+// 添加请求完成后执行的回调（返回结果已经输出），可用于记录日志、统计耗时及清理资源
+// 回调按添加顺序执行，回调中的panic只记录日志
+func (self *Context) After(fn func(f *Finish)) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.afters = append(self.afters, fn)
+}
+
+// 执行请求完成后的回调
+func (self *Context) finish(f *Finish) {
+	f.Method = self.Req.Method
+	f.Route = self.route
+	f.Duration = time.Since(self.start)
+
+	self.mu.Lock()
+	afters := self.afters
+	self.mu.Unlock()
+	for _, fn := range afters {
+		self.callAfter(fn, f)
+	}
+}
+
+// 执行回调，回调中的panic只记录日志
+func (self *Context) callAfter(fn func(f *Finish), f *Finish) {
+	defer func() {
+		if err := recover(); err != nil {
+			self.logger.Error("请求完成回调异常 - " + self.Req.Method + " - " + self.Req.URL.Path + " - " + fmt.Sprint(err))
+		}
+	}()
+	fn(f)
+}
+
+// 添加请求结束后的清理函数（如删除上传的临时文件），在处理器执行完毕后执行
+// 与After不同，ServeHTTP中发生panic时也会执行；处理器超时仍在后台执行时，等处理器执行完毕后再执行
+func (self *Context) onCleanup(fn func()) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.cleanups = append(self.cleanups, fn)
+}
+
+// 执行清理函数（ServeHTTP返回时调用）
+func (self *Context) cleanup() {
+	run := func() {
+		self.mu.Lock()
+		cleanups := self.cleanups
+		self.mu.Unlock()
+		for _, fn := range cleanups {
+			self.callCleanup(fn)
+		}
+	}
+
+	if self.running == nil {
+		run()
+		return
+	}
+	select {
+	case <-self.running:
+		run()
+	default:
+		go func() {
+			<-self.running
+			run()
+		}()
+	}
+}
+
+// 执行清理函数，清理函数中的panic只记录日志
+func (self *Context) callCleanup(fn func()) {
+	defer func() {
+		if err := recover(); err != nil {
+			self.logger.Error("请求清理异常 - " + self.Req.Method + " - " + self.Req.URL.Path + " - " + fmt.Sprint(err))
+		}
+	}()
+	fn()
+}
@@ -0,0 +1,120 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"reflect"
+	"runtime"
+)
+
+// 内置注入类型
+var (
+	contextType = reflect.TypeOf((*Context)(nil))
+	loggerType  = reflect.TypeOf((*Logger)(nil))
+)
+
+// 预编译的处理器（注册时解析一次函数签名，请求时不再重复反射）
+type handler struct {
+	name   string
+	in     []reflect.Type
+	body   []bool
+	invoke func(ctx *Context) bool
+}
+
+// 编译处理器
+func compile(h Handler) *handler {
+	chkHandler(h)
+
+	v := reflect.ValueOf(h)
+	t := v.Type()
+	c := &handler{
+		name: runtime.FuncForPC(v.Pointer()).Name(),
+		in:   make([]reflect.Type, t.NumIn()),
+		body: make([]bool, t.NumIn()),
+	}
+	for i := range c.in {
+		c.in[i] = t.In(i)
+		c.body[i] = isBody(c.in[i])
+		if c.body[i] {
+			// 注册时检查validate标签
+			checkRules(c.in[i])
+		}
+	}
+
+	// 常用函数签名直接调用，无需反射
+	switch fn := h.(type) {
+	case func(*Context):
+		c.invoke = func(ctx *Context) bool {
+			fn(ctx)
+			return true
+		}
+	case func(*Context, *Logger):
+		c.invoke = func(ctx *Context) bool {
+			fn(ctx, ctx.logger)
+			return true
+		}
+	case func(*Context) error:
+		c.invoke = func(ctx *Context) bool {
+			if err := fn(ctx); err != nil {
+				ctx.errorWrapper(err)
+				return false
+			}
+			ctx.success()
+			return true
+		}
+	case func(*Context, *Logger) error:
+		c.invoke = func(ctx *Context) bool {
+			if err := fn(ctx, ctx.logger); err != nil {
+				ctx.errorWrapper(err)
+				return false
+			}
+			ctx.success()
+			return true
+		}
+	default:
+		c.invoke = func(ctx *Context) bool {
+			// 依赖注入参数
+			params := make([]reflect.Value, len(c.in))
+			for i, typ := range c.in {
+				var val reflect.Value
+				var err error
+				if c.body[i] {
+					val, err = ctx.bindBody(typ)
+				} else {
+					val, err = ctx.getVal(typ)
+				}
+				if err != nil {
+					ctx.errorWrapper(err)
+					return false
+				}
+				params[i] = val
+			}
+
+			// 执行handle并处理返回值
+			return ctx.result(v.Call(params))
+		}
+	}
+
+	return c
+}
+
+// 批量编译处理器
+func compileAll(hs []Handler) []*handler {
+	cs := make([]*handler, len(hs))
+	for i, h := range hs {
+		cs[i] = compile(h)
+	}
+	return cs
+}
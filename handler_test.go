@@ -0,0 +1,81 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+type benchService struct {
+	name string
+}
+
+// 执行b.N次GET /bench请求
+func benchmarkServeHTTP(b *testing.B, setup func(cos *Cosine)) {
+	cos := New()
+	setup(cos)
+	r := httptest.NewRequest("GET", "/bench", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cos.ServeHTTP(httptest.NewRecorder(), r)
+	}
+}
+
+func BenchmarkServeHTTP_Context(b *testing.B) {
+	benchmarkServeHTTP(b, func(cos *Cosine) {
+		cos.GET("/bench", func(ctx *Context) {
+			ctx.Res.DataWrapper("ok")
+		})
+	})
+}
+
+func BenchmarkServeHTTP_ContextLogger(b *testing.B) {
+	benchmarkServeHTTP(b, func(cos *Cosine) {
+		cos.GET("/bench", func(ctx *Context, log *Logger) {
+			ctx.Res.DataWrapper("ok")
+		})
+	})
+}
+
+func BenchmarkServeHTTP_ContextError(b *testing.B) {
+	benchmarkServeHTTP(b, func(cos *Cosine) {
+		cos.GET("/bench", func(ctx *Context) error {
+			ctx.Res.DataWrapper("ok")
+			return nil
+		})
+	})
+}
+
+// 反射路径：注入参数并自动封装返回值
+func BenchmarkServeHTTP_Injected(b *testing.B) {
+	benchmarkServeHTTP(b, func(cos *Cosine) {
+		cos.Map(&benchService{"ok"})
+		cos.GET("/bench", func(ctx *Context, s *benchService) string {
+			return s.name
+		})
+	})
+}
+
+// 反射路径：无参数，自动封装返回值
+func BenchmarkServeHTTP_Return(b *testing.B) {
+	benchmarkServeHTTP(b, func(cos *Cosine) {
+		cos.GET("/bench", func() (string, error) {
+			return "ok", nil
+		})
+	})
+}
@@ -0,0 +1,167 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// 注入对象集合（按类型索引）
+type injector map[reflect.Type]reflect.Value
+
+// 获取接口指针对应的接口类型，如(*io.Writer)(nil)
+func interfaceOf(ifacePtr interface{}) reflect.Type {
+	t := reflect.TypeOf(ifacePtr)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		panic("Cosine要求MapTo的第二个参数必须是接口指针，如(*io.Writer)(nil)")
+	}
+	return t.Elem()
+}
+
+// 按接口类型添加注入对象
+func (self injector) mapTo(v interface{}, ifacePtr interface{}) {
+	t := interfaceOf(ifacePtr)
+	val := reflect.ValueOf(v)
+	if !val.IsValid() || !val.Type().Implements(t) {
+		panic(fmt.Sprintf("Cosine要求MapTo的对象必须实现接口%s", t))
+	}
+	self[t] = val
+}
+
+// 查找所有可赋值给接口t的注入类型（只查找按具体类型映射的对象）
+func (self injector) assignable(t reflect.Type) []reflect.Type {
+	var types []reflect.Type
+	for k := range self {
+		if k.Kind() != reflect.Interface && k.Implements(t) {
+			types = append(types, k)
+		}
+	}
+	return types
+}
+
+// 从多个候选类型中选出唯一可注入接口t的类型
+func unique(t reflect.Type, types ...[]reflect.Type) (reflect.Type, error) {
+	// 去除重复的类型（请求中映射的对象会覆盖全局对象）
+	var found []reflect.Type
+	seen := make(map[reflect.Type]bool)
+	for _, ts := range types {
+		for _, typ := range ts {
+			if !seen[typ] {
+				seen[typ] = true
+				found = append(found, typ)
+			}
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("找不到类型为%s的注入对象", t)
+	case 1:
+		return found[0], nil
+	}
+
+	names := make([]string, len(found))
+	for i, typ := range found {
+		names[i] = typ.String()
+	}
+	return nil, fmt.Errorf("接口%s存在多个可注入的对象：%s，请使用MapTo指定", t, strings.Join(names, ", "))
+}
+
+// 请求级别的对象提供者
+type provider struct {
+	fn     reflect.Value
+	hasErr bool
+}
+
+// 提供者集合（按返回值类型索引）
+type providers map[reflect.Type]*provider
+
+// 添加提供者，fn的格式为func(*Context) (T, error)或func(*Context) T
+func (self providers) add(fn interface{}) {
+	t := reflect.TypeOf(fn)
+	if t == nil || t.Kind() != reflect.Func || t.NumIn() != 1 || t.In(0) != contextType {
+		panic("Cosine要求Provide的参数必须是func(*Context) (T, error)或func(*Context) T")
+	}
+
+	p := &provider{fn: reflect.ValueOf(fn)}
+	switch {
+	case t.NumOut() == 1 && t.Out(0) != errorType:
+	case t.NumOut() == 2 && t.Out(0) != errorType && t.Out(1) == errorType:
+		p.hasErr = true
+	default:
+		panic("Cosine要求Provide的参数必须是func(*Context) (T, error)或func(*Context) T")
+	}
+	self[t.Out(0)] = p
+}
+
+// 查找所有返回值可赋值给接口t的提供者类型
+func (self providers) assignable(t reflect.Type) []reflect.Type {
+	var types []reflect.Type
+	for k := range self {
+		if k.Implements(t) {
+			types = append(types, k)
+		}
+	}
+	return types
+}
+
+// 执行提供者
+func (self *provider) call(ctx *Context) (reflect.Value, error) {
+	out := self.fn.Call([]reflect.Value{reflect.ValueOf(ctx)})
+	if self.hasErr && !out[1].IsNil() {
+		return reflect.Value{}, out[1].Interface().(error)
+	}
+	return out[0], nil
+}
+
+// 判断类型t是否可以注入：内置对象、全局对象、提供者、声明的请求对象以及请求数据
+func (self *Cosine) injectable(t reflect.Type) error {
+	switch t {
+	case contextType, stdContextType, loggerType:
+		return nil
+	}
+	if _, ok := self.injts[t]; ok {
+		return nil
+	}
+	if _, ok := self.providers[t]; ok {
+		return nil
+	}
+	if _, ok := self.declared[t]; ok {
+		return nil
+	}
+	if isBody(t) {
+		return nil
+	}
+	if t.Kind() == reflect.Interface {
+		_, err := unique(t, self.injts.assignable(t), self.providers.assignable(t), self.declared.assignable(t))
+		return err
+	}
+	return fmt.Errorf("找不到类型为%s的注入对象", t)
+}
+
+// 校验处理器的所有参数是否可以注入
+func (self *Cosine) verify(route string, hs []*handler) []string {
+	var errs []string
+	for _, h := range hs {
+		for _, t := range h.in {
+			if err := self.injectable(t); err != nil {
+				errs = append(errs, route+" - "+h.name+" - "+err.Error())
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,62 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"strings"
+	"testing"
+)
+
+type testSession struct {
+	user string
+}
+
+func TestMiddlewareMap(t *testing.T) {
+	cos := New()
+	cos.Use(func(ctx *Context) {
+		ctx.Map(&testSession{"alice"})
+	})
+	cos.GET("/session", func(s *testSession) string {
+		return s.user
+	})
+
+	if got := serve(cos, "GET", "/session"); got != `{"code":200,"message":"","data":"alice"}` {
+		t.Fatalf("got %s", got)
+	}
+
+	// 未声明时严格校验失败，声明后通过
+	if err := cos.Verify(); err == nil {
+		t.Fatal("expected Verify error for undeclared type")
+	}
+	cos.Declare((*testSession)(nil))
+	if err := cos.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunFailsVerify(t *testing.T) {
+	cos := New()
+	cos.GET("/session", func(s *testSession) string {
+		return s.user
+	})
+
+	defer func() {
+		p, _ := recover().(string)
+		if !strings.Contains(p, "GET /session") || !strings.Contains(p, "testSession") {
+			t.Fatalf("got panic %q", p)
+		}
+	}()
+	cos.Run()
+}
@@ -0,0 +1,186 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// 请求数据为空（使用RequireBody时）
+var ErrEmptyBody = NewError(400, "请求数据不能为空")
+
+// JSON解析错误详情
+type JSONError struct {
+	Field  string `json:"field,omitempty"` // 出错的字段
+	Offset int64  `json:"offset"`          // 出错的位置（字节）
+	Reason string `json:"reason"`          // 错误原因
+}
+
+// JSON解析选项
+type JSONOption func(o *jsonOptions)
+
+// JSON解析选项
+type jsonOptions struct {
+	disallowUnknownFields bool
+	useNumber             bool
+	requireBody           bool
+}
+
+// 请求数据中存在结构体中没有的字段时返回错误
+func DisallowUnknownFields() JSONOption {
+	return func(o *jsonOptions) {
+		o.disallowUnknownFields = true
+	}
+}
+
+// 数字解析成json.Number而不是float64
+func UseNumber() JSONOption {
+	return func(o *jsonOptions) {
+		o.useNumber = true
+	}
+}
+
+// 请求数据为空时返回ErrEmptyBody
+func RequireBody() JSONOption {
+	return func(o *jsonOptions) {
+		o.requireBody = true
+	}
+}
+
+// 设置全局JSON解析选项（DataToJSON、ReadJSON、Bind及请求数据注入共用）
+func (self *Cosine) SetJSONOptions(opts ...JSONOption) {
+	self.jsonOptions = opts
+}
+
+// 判断请求中是否有数据（GET、HEAD、DELETE请求始终返回false）
+func (self *Context) HasBody() bool {
+	data, err := self.ReadData()
+	return err == nil && len(data) > 0
+}
+
+// 将提交的JSON数据解析到v并根据validate标签校验，opts在全局选项之后生效
+// 请求数据为空时不解析（可以使用HasBody判断，或使用RequireBody选项返回错误）
+// 解析失败时返回code为400的*Error，details为出错的字段及位置
+func (self *Context) ReadJSON(v interface{}, opts ...JSONOption) error {
+	if err := self.unmarshalJSON(v, opts...); err != nil {
+		return err
+	}
+	return Validate(v)
+}
+
+// 将提交的JSON数据解析到v
+func (self *Context) unmarshalJSON(v interface{}, opts ...JSONOption) error {
+	o := new(jsonOptions)
+	for _, opt := range self.Cosine.jsonOptions {
+		opt(o)
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	data, err := self.ReadData()
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		if o.requireBody {
+			return ErrEmptyBody
+		}
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if o.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if o.useNumber {
+		dec.UseNumber()
+	}
+	if err = dec.Decode(v); err != nil {
+		return jsonErr(err, int64(len(data)))
+	}
+	// 只允许一个JSON值
+	if _, err = dec.Token(); err != io.EOF {
+		return NewError(400, "请求数据格式错误").WithDetails(&JSONError{Offset: dec.InputOffset(), Reason: "JSON数据之后存在多余的内容"})
+	}
+	return nil
+}
+
+// 将JSON解析错误转换成code为400的*Error
+// 错误原因只包含JSON字段及类型，不包含Go的类型及字段名称
+func jsonErr(err error, size int64) error {
+	detail := &JSONError{Reason: "JSON数据格式错误"}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var invalidErr *json.InvalidUnmarshalError
+	switch {
+	case errors.As(err, &invalidErr):
+		// 解析目标不是非nil的指针，属于代码错误
+		return err
+	case errors.As(err, &syntaxErr):
+		detail.Offset = syntaxErr.Offset
+		detail.Reason = "JSON语法错误：" + syntaxErr.Error()
+	case errors.As(err, &typeErr):
+		detail.Field = typeErr.Field
+		detail.Offset = typeErr.Offset
+		// Value为JSON值的类型，数字超出范围时为“number 300”
+		value := strings.SplitN(typeErr.Value, " ", 2)[0]
+		detail.Reason = "类型错误：应为" + jsonKind(typeErr.Type) + "，实际为" + value
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		detail.Offset = size
+		detail.Reason = "JSON数据不完整"
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		detail.Field = strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		detail.Reason = "未知字段"
+	}
+	return NewError(400, "请求数据格式错误").WithDetails(detail)
+}
+
+// 获取Go类型对应的JSON类型
+func jsonKind(t reflect.Type) string {
+	if t == nil {
+		return "value"
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		return "array"
+	case reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return "value"
+}
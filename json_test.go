@@ -0,0 +1,59 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type jsonItem struct {
+	Count int `json:"count"`
+}
+
+type jsonOrder struct {
+	Name  string      `json:"name"`
+	Items []*jsonItem `json:"items"`
+	Tags  []string    `json:"tags"`
+}
+
+func TestJSONErrorReason(t *testing.T) {
+	cos := New()
+	cos.POST("/order", func(ctx *Context) error {
+		return ctx.ReadJSON(new(jsonOrder), DisallowUnknownFields())
+	})
+
+	for body, want := range map[string]string{
+		`{"name":1}`:                  `"field":"name","offset":9,"reason":"类型错误：应为string，实际为number"`,
+		`{"items":[{"count":"a"}]}`:   `count","offset":22,"reason":"类型错误：应为integer，实际为string"`,
+		`{"items":[{"count":1e100}]}`: `count","offset":24,"reason":"类型错误：应为integer，实际为number"`,
+		`{"tags":{}}`:                 `"field":"tags","offset":9,"reason":"类型错误：应为array，实际为object"`,
+		`{"other":1}`:                 `"field":"other","offset":0,"reason":"未知字段"`,
+		`{"name":`:                    `"offset":8,"reason":"JSON数据不完整"`,
+	} {
+		w := httptest.NewRecorder()
+		cos.ServeHTTP(w, httptest.NewRequest("POST", "/order", strings.NewReader(body)))
+		got := w.Body.String()
+		if !strings.Contains(got, want) {
+			t.Errorf("%s: got %s, want %s", body, got, want)
+		}
+		for _, name := range []string{"Go", "jsonOrder", "jsonItem", "cosine.", "Count", "Items"} {
+			if strings.Contains(got, name) {
+				t.Errorf("%s: %s contains %q", body, got, name)
+			}
+		}
+	}
+}
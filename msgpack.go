@@ -0,0 +1,353 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// MessagePack数据格式错误
+var errMsgpack = errors.New("MessagePack数据格式错误")
+
+// 数组及map的最大嵌套层数（与encoding/json相同）
+const maxMsgpackDepth = 10000
+
+// 将MessagePack数据解析到v（先解析成通用结构，再按JSON规则赋值，因此支持json标签）
+func unmarshalMsgpack(data []byte, v interface{}) error {
+	d := &msgpackDecoder{data: data}
+	val, err := d.decode()
+	if err != nil {
+		return err
+	}
+	if d.pos != len(d.data) {
+		return errMsgpack
+	}
+
+	b, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// MessagePack解析器
+type msgpackDecoder struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+// 读取n个字节
+func (self *msgpackDecoder) read(n int) ([]byte, error) {
+	if n < 0 || self.pos+n > len(self.data) {
+		return nil, errMsgpack
+	}
+	b := self.data[self.pos : self.pos+n]
+	self.pos += n
+	return b, nil
+}
+
+// 读取n个字节表示的无符号整数
+func (self *msgpackDecoder) uint(n int) (uint64, error) {
+	b, err := self.read(n)
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// 读取n个字节表示的有符号整数
+func (self *msgpackDecoder) int(n int) (int64, error) {
+	u, err := self.uint(n)
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case 1:
+		return int64(int8(u)), nil
+	case 2:
+		return int64(int16(u)), nil
+	case 4:
+		return int64(int32(u)), nil
+	}
+	return int64(u), nil
+}
+
+// 解析一个值
+func (self *msgpackDecoder) decode() (interface{}, error) {
+	b, err := self.read(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c >= 0x80 && c <= 0x8f:
+		return self.decodeMap(int(c & 0x0f))
+	case c >= 0x90 && c <= 0x9f:
+		return self.decodeArray(int(c & 0x0f))
+	case c >= 0xa0 && c <= 0xbf:
+		return self.decodeString(int(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := self.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return self.read(int(n))
+	case 0xca:
+		u, err := self.uint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := self.uint(8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return self.uint(1 << (c - 0xcc))
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		return self.int(1 << (c - 0xd0))
+	case 0xd9, 0xda, 0xdb:
+		n, err := self.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return self.decodeString(int(n))
+	case 0xdc, 0xdd:
+		n, err := self.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return self.decodeArray(int(n))
+	case 0xde, 0xdf:
+		n, err := self.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return self.decodeMap(int(n))
+	}
+
+	// 不支持扩展类型
+	return nil, errMsgpack
+}
+
+// 解析字符串
+func (self *msgpackDecoder) decodeString(n int) (interface{}, error) {
+	b, err := self.read(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// 进入一层数组或map，超过最大嵌套层数时返回错误
+func (self *msgpackDecoder) enter() error {
+	if self.depth++; self.depth > maxMsgpackDepth {
+		return errMsgpack
+	}
+	return nil
+}
+
+// 解析数组
+func (self *msgpackDecoder) decodeArray(n int) (interface{}, error) {
+	if n > len(self.data)-self.pos {
+		return nil, errMsgpack
+	}
+	if err := self.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { self.depth-- }()
+	arr := make([]interface{}, n)
+	for i := range arr {
+		v, err := self.decode()
+		if err != nil {
+			return nil, err
+		}
+		arr[i] = v
+	}
+	return arr, nil
+}
+
+// 解析map（键转换成字符串）
+func (self *msgpackDecoder) decodeMap(n int) (interface{}, error) {
+	if n > len(self.data)-self.pos {
+		return nil, errMsgpack
+	}
+	if err := self.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { self.depth-- }()
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, err := self.decode()
+		if err != nil {
+			return nil, err
+		}
+		v, err := self.decode()
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := k.(string); ok {
+			m[s] = v
+		} else {
+			m[fmt.Sprint(k)] = v
+		}
+	}
+	return m, nil
+}
+
+// 序列化成MessagePack（先按JSON规则转换成通用结构，因此支持json标签）
+func marshalMsgpack(v interface{}) ([]byte, error) {
+	val, err := toGeneric(v)
+	if err != nil {
+		return nil, err
+	}
+	e := new(msgpackEncoder)
+	if err = e.encode(val); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+// MessagePack序列化器
+type msgpackEncoder struct {
+	buf bytes.Buffer
+}
+
+// 写入类型及长度
+func (self *msgpackEncoder) head(fix, c8, c16, c32 byte, fixMax, n int) {
+	switch {
+	case n <= fixMax:
+		self.buf.WriteByte(fix | byte(n))
+	case c8 != 0 && n <= math.MaxUint8:
+		self.buf.WriteByte(c8)
+		self.buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		self.buf.WriteByte(c16)
+		binary.Write(&self.buf, binary.BigEndian, uint16(n))
+	default:
+		self.buf.WriteByte(c32)
+		binary.Write(&self.buf, binary.BigEndian, uint32(n))
+	}
+}
+
+// 序列化一个值
+func (self *msgpackEncoder) encode(v interface{}) error {
+	switch val := v.(type) {
+	case nil:
+		self.buf.WriteByte(0xc0)
+	case bool:
+		if val {
+			self.buf.WriteByte(0xc3)
+		} else {
+			self.buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		return self.encodeNumber(val)
+	case string:
+		self.head(0xa0, 0xd9, 0xda, 0xdb, 31, len(val))
+		self.buf.WriteString(val)
+	case []interface{}:
+		self.head(0x90, 0, 0xdc, 0xdd, 15, len(val))
+		for _, item := range val {
+			if err := self.encode(item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		self.head(0x80, 0, 0xde, 0xdf, 15, len(val))
+		for _, k := range keys {
+			self.encode(k)
+			if err := self.encode(val[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return errMsgpack
+	}
+	return nil
+}
+
+// 序列化数字：整数使用最短的格式，其余使用float64
+func (self *msgpackEncoder) encodeNumber(n json.Number) error {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		switch {
+		case i >= 0 && i <= 0x7f, i < 0 && i >= -32:
+			self.buf.WriteByte(byte(i))
+		case i > 0 && i <= math.MaxUint8:
+			self.buf.Write([]byte{0xcc, byte(i)})
+		case i > 0 && i <= math.MaxUint16:
+			self.buf.WriteByte(0xcd)
+			binary.Write(&self.buf, binary.BigEndian, uint16(i))
+		case i > 0 && i <= math.MaxUint32:
+			self.buf.WriteByte(0xce)
+			binary.Write(&self.buf, binary.BigEndian, uint32(i))
+		case i >= math.MinInt8 && i < 0:
+			self.buf.Write([]byte{0xd0, byte(i)})
+		case i >= math.MinInt16 && i < 0:
+			self.buf.WriteByte(0xd1)
+			binary.Write(&self.buf, binary.BigEndian, int16(i))
+		case i >= math.MinInt32 && i < 0:
+			self.buf.WriteByte(0xd2)
+			binary.Write(&self.buf, binary.BigEndian, int32(i))
+		default:
+			self.buf.WriteByte(0xd3)
+			binary.Write(&self.buf, binary.BigEndian, i)
+		}
+		return nil
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		self.buf.WriteByte(0xcf)
+		binary.Write(&self.buf, binary.BigEndian, u)
+		return nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return err
+	}
+	self.buf.WriteByte(0xcb)
+	binary.Write(&self.buf, binary.BigEndian, f)
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"bytes"
+	"math"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type msgpackItem struct {
+	Name  string            `json:"name"`
+	Tags  []string          `json:"tags"`
+	Attrs map[string]string `json:"attrs"`
+}
+
+type msgpackValue struct {
+	Nil    *int          `json:"nil"`
+	True   bool          `json:"true"`
+	False  bool          `json:"false"`
+	Ints   []int64       `json:"ints"`
+	Uint   uint64        `json:"uint"`
+	Floats []float64     `json:"floats"`
+	Strs   []string      `json:"strs"`
+	Items  []msgpackItem `json:"items"`
+	Big    []int         `json:"big"`
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	attrs := make(map[string]string)
+	for i := 0; i < 20; i++ {
+		attrs[strings.Repeat("k", i+1)] = strings.Repeat("v", i)
+	}
+	in := &msgpackValue{
+		True: true,
+		Ints: []int64{0, 1, 127, 128, 255, 256, 65535, 65536, math.MaxUint32, math.MaxUint32 + 1, math.MaxInt64,
+			-1, -32, -33, -128, -129, -32768, -32769, math.MinInt32, math.MinInt32 - 1, math.MinInt64},
+		Uint:   math.MaxUint64,
+		Floats: []float64{0.5, -1.25, 1e300},
+		Strs:   []string{"", "中文", strings.Repeat("a", 31), strings.Repeat("a", 32), strings.Repeat("a", 256), strings.Repeat("a", 65536)},
+		Items: []msgpackItem{
+			{Name: "a", Tags: []string{"x", "y"}, Attrs: map[string]string{"k": "v"}},
+			{Name: "b", Attrs: attrs},
+		},
+		Big: make([]int, 70000),
+	}
+
+	data, err := marshalMsgpack(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(msgpackValue)
+	if err = unmarshalMsgpack(data, out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("round trip mismatch:\n%+v\n%+v", in, out)
+	}
+}
+
+func TestMsgpackTruncated(t *testing.T) {
+	data, err := marshalMsgpack(map[string]interface{}{
+		"s": strings.Repeat("a", 300),
+		"a": []interface{}{1, 70000, -70000, 1.5, true, nil},
+		"m": map[string]interface{}{"k": "v"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(data); i++ {
+		var v interface{}
+		if err := unmarshalMsgpack(data[:i], &v); err != errMsgpack {
+			t.Fatalf("truncated at %d: got %v", i, err)
+		}
+	}
+}
+
+func TestMsgpackTrailingData(t *testing.T) {
+	var v interface{}
+	if err := unmarshalMsgpack([]byte{0xc0, 0xc0}, &v); err != errMsgpack {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestMsgpackOversizedLength(t *testing.T) {
+	for _, data := range [][]byte{
+		{0xdb, 0xff, 0xff, 0xff, 0xff},      // str32
+		{0xc6, 0xff, 0xff, 0xff, 0xff},      // bin32
+		{0xdd, 0xff, 0xff, 0xff, 0xff},      // array32
+		{0xdf, 0xff, 0xff, 0xff, 0xff},      // map32
+		{0xdc, 0xff, 0xff, 0xc0},            // array16
+		{0xde, 0x00, 0x02, 0xa1, 'k', 0xc0}, // map16
+		{0xd9, 0x05, 'a', 'b'},              // str8
+	} {
+		var v interface{}
+		if err := unmarshalMsgpack(data, &v); err != errMsgpack {
+			t.Fatalf("% x: got %v", data, err)
+		}
+	}
+}
+
+func TestMsgpackDepth(t *testing.T) {
+	// 最大嵌套层数以内可以解析
+	data := append(bytes.Repeat([]byte{0x91}, maxMsgpackDepth), 0xc0)
+	if _, err := (&msgpackDecoder{data: data}).decode(); err != nil {
+		t.Fatalf("depth %d: %v", maxMsgpackDepth, err)
+	}
+
+	// 超过最大嵌套层数
+	for _, c := range []byte{0x91, 0x81} {
+		data := bytes.Repeat([]byte{c}, maxMsgpackDepth+1)
+		if c == 0x81 {
+			data = bytes.Repeat([]byte{0x81, 0xa1, 'k'}, maxMsgpackDepth+1)
+		}
+		data = append(data, 0xc0)
+		if _, err := (&msgpackDecoder{data: data}).decode(); err != errMsgpack {
+			t.Fatalf("% x: got %v", c, err)
+		}
+	}
+}
+
+func TestMsgpackDeepBody(t *testing.T) {
+	type payload struct {
+		Body
+		Name string `json:"name"`
+	}
+	cos := New()
+	cos.POST("/msgpack", func(p *payload) string {
+		return p.Name
+	})
+
+	r := httptest.NewRequest("POST", "/msgpack", bytes.NewReader(bytes.Repeat([]byte{0x91}, 1<<20)))
+	r.Header.Set("Content-Type", "application/msgpack")
+	w := httptest.NewRecorder()
+	cos.ServeHTTP(w, r)
+	if !strings.Contains(w.Body.String(), `"code":400`) {
+		t.Fatalf("got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+// 分页配置
+type pageConfig struct {
+	size    int // 默认每页数量
+	maxSize int // 每页数量的最大值
+}
+
+// 读取分页配置
+func newPageConfig() *pageConfig {
+	conf := &pageConfig{size: 20, maxSize: 100}
+	for name, p := range map[string]*int{"page.size": &conf.size, "page.maxsize": &conf.maxSize} {
+		if s := os.Getenv(name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 {
+				panic(name + "配置错误")
+			}
+			*p = n
+		}
+	}
+	if conf.size > conf.maxSize {
+		conf.size = conf.maxSize
+	}
+	return conf
+}
+
+// 页码分页的请求参数
+type PageQuery struct {
+	Page   int // 页码，从1开始
+	Size   int // 每页数量
+	Offset int // 偏移量，即(Page-1)*Size
+}
+
+// 游标分页的请求参数
+type CursorQuery struct {
+	Cursor string // 游标，第一页为空字符串
+	Size   int    // 每页数量
+}
+
+// 页码分页的返回信息
+type PageMeta struct {
+	Page       int   `json:"page"`
+	Size       int   `json:"size"`
+	Total      int64 `json:"total"`
+	TotalPages int64 `json:"total_pages"`
+}
+
+// 游标分页的返回信息
+type CursorMeta struct {
+	Size       int    `json:"size"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// 获取查询参数中的page及size，未提交时page为1，size为page.size（默认20）
+// size超过page.maxsize（默认100）时使用page.maxsize，参数格式错误或偏移量超出范围时返回code为400的*Error
+func (self *Context) Page() (*PageQuery, error) {
+	page, err := self.positiveQuery("page", 1)
+	if err != nil {
+		return nil, err
+	}
+	size, err := self.pageSize()
+	if err != nil {
+		return nil, err
+	}
+	// 偏移量超出int范围
+	if page-1 > math.MaxInt/size {
+		return nil, self.QueryParams().invalid("page")
+	}
+	return &PageQuery{Page: page, Size: size, Offset: (page - 1) * size}, nil
+}
+
+// 获取查询参数中的cursor及size，size的规则与Page相同
+func (self *Context) Cursor() (*CursorQuery, error) {
+	size, err := self.pageSize()
+	if err != nil {
+		return nil, err
+	}
+	return &CursorQuery{Cursor: self.Query("cursor"), Size: size}, nil
+}
+
+// 获取每页数量
+func (self *Context) pageSize() (int, error) {
+	conf := self.Cosine.page
+	size, err := self.positiveQuery("size", conf.size)
+	if size > conf.maxSize {
+		size = conf.maxSize
+	}
+	return size, err
+}
+
+// 获取正整数查询参数，未提交时返回def
+func (self *Context) positiveQuery(name string, def int) (int, error) {
+	params := self.QueryParams()
+	if !params.Has(name) {
+		return def, nil
+	}
+	n, err := params.Int(name)
+	if err != nil {
+		return 0, err
+	}
+	if n < 1 {
+		return 0, params.invalid(name)
+	}
+	return n, nil
+}
+
+// 分页信息（输出时生成links及Link头）
+type pagination struct {
+	page  int
+	size  int
+	pages int64
+	next  string
+	prev  string
+	// 是否为游标分页
+	cursor bool
+}
+
+// 设置页码分页的返回结果，total为总数量
+// 返回结果中增加meta及links（self、first、prev、next、last），并设置Link头
+func (self *Response) PageWrapper(items interface{}, page, size int, total int64) {
+	self.DataWrapper(items)
+	pages := int64(0)
+	if size > 0 {
+		pages = (total + int64(size) - 1) / int64(size)
+	}
+	self.Meta = &PageMeta{Page: page, Size: size, Total: total, TotalPages: pages}
+	self.page = &pagination{page: page, size: size, pages: pages}
+}
+
+// 设置游标分页的返回结果，next、prev为下一页及上一页的游标，没有时为空字符串
+// 返回结果中增加meta及links（self、next、prev），并设置Link头
+func (self *Response) CursorWrapper(items interface{}, size int, next, prev string) {
+	self.DataWrapper(items)
+	self.Meta = &CursorMeta{Size: size, NextCursor: next, PrevCursor: prev, HasMore: next != ""}
+	self.page = &pagination{size: size, next: next, prev: prev, cursor: true}
+}
+
+// 根据请求地址生成分页链接，并写入Link头
+func (self *Response) writeLinks(w http.ResponseWriter, r *http.Request) {
+	p := self.page
+	if p == nil {
+		return
+	}
+
+	link := func(params map[string]string) string {
+		u := *r.URL
+		q := u.Query()
+		for k, v := range params {
+			if v == "" {
+				q.Del(k)
+			} else {
+				q.Set(k, v)
+			}
+		}
+		u.RawQuery = q.Encode()
+		return u.RequestURI()
+	}
+	size := strconv.Itoa(p.size)
+	self.Links = map[string]string{"self": r.URL.RequestURI()}
+	if p.cursor {
+		if p.next != "" {
+			self.Links["next"] = link(map[string]string{"cursor": p.next, "size": size})
+		}
+		if p.prev != "" {
+			self.Links["prev"] = link(map[string]string{"cursor": p.prev, "size": size})
+		}
+	} else {
+		page := func(n int64) string {
+			return link(map[string]string{"page": strconv.FormatInt(n, 10), "size": size})
+		}
+		self.Links["first"] = page(1)
+		if p.pages > 0 {
+			self.Links["last"] = page(p.pages)
+		}
+		if p.page > 1 {
+			self.Links["prev"] = page(int64(p.page) - 1)
+		}
+		if int64(p.page) < p.pages {
+			self.Links["next"] = page(int64(p.page) + 1)
+		}
+	}
+
+	for _, rel := range []string{"first", "prev", "next", "last"} {
+		if href, ok := self.Links[rel]; ok {
+			w.Header().Add("Link", "<"+href+`>; rel="`+rel+`"`)
+		}
+	}
+}
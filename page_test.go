@@ -0,0 +1,49 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestPage(t *testing.T) {
+	t.Setenv("page.maxsize", "100")
+	cos := New()
+	cos.GET("/list", func(ctx *Context) (interface{}, error) {
+		p, err := ctx.Page()
+		if err != nil {
+			return nil, err
+		}
+		return []int{p.Page, p.Size, p.Offset}, nil
+	})
+
+	maxPage := strconv.Itoa(math.MaxInt/100 + 1)
+	tooLarge := strconv.Itoa(math.MaxInt/100 + 2)
+	for query, want := range map[string]string{
+		"":                                  `{"code":200,"message":"","data":[1,20,0]}`,
+		"?page=3&size=10":                   `{"code":200,"message":"","data":[3,10,20]}`,
+		"?size=500":                         `{"code":200,"message":"","data":[1,100,0]}`,
+		"?page=0":                           `{"code":400,"message":"查询参数格式错误：page","data":null}`,
+		"?page=" + maxPage + "&size=100":    `{"code":200,"message":"","data":[` + maxPage + `,100,` + strconv.Itoa((math.MaxInt/100)*100) + `]}`,
+		"?page=" + tooLarge + "&size=100":   `{"code":400,"message":"查询参数格式错误：page","data":null}`,
+		"?page=100000000000000000&size=100": `{"code":400,"message":"查询参数格式错误：page","data":null}`,
+	} {
+		if got := serve(cos, "GET", "/list"+query); got != want {
+			t.Errorf("%s: got %s, want %s", query, got, want)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"strconv"
+	"strings"
+)
+
+// 请求参数（路径参数、查询参数、请求头、Cookie共用）
+// 参数不存在或格式错误时返回code为400的*Error，Must*方法则直接返回code为400的结果
+type Params struct {
+	source string
+	get    func(name string) []string
+}
+
+// 获取路径参数
+func (self *Context) PathParams() *Params {
+	return &Params{"路径参数", func(name string) []string {
+		if v, ok := self.params[name]; ok && v != nil {
+			if s, ok := v.(string); ok {
+				return []string{s}
+			}
+		}
+		return nil
+	}}
+}
+
+// 获取查询参数
+func (self *Context) QueryParams() *Params {
+	return &Params{"查询参数", func(name string) []string {
+		return self.queryValues()[name]
+	}}
+}
+
+// 获取请求头
+func (self *Context) HeaderParams() *Params {
+	return &Params{"请求头", func(name string) []string {
+		return self.Req.Header.Values(name)
+	}}
+}
+
+// 获取Cookie
+func (self *Context) CookieParams() *Params {
+	return &Params{"Cookie", func(name string) []string {
+		c, err := self.Req.Cookie(name)
+		if err != nil {
+			return nil
+		}
+		return []string{c.Value}
+	}}
+}
+
+// 获取请求头
+func (self *Context) Header(name string) string {
+	return self.Req.Header.Get(name)
+}
+
+// 获取Cookie的值，Cookie不存在时返回空字符串
+func (self *Context) Cookie(name string) string {
+	s, _ := self.CookieParams().String(name)
+	return s
+}
+
+// 将请求头绑定到结构体（使用header标签），绑定或校验失败时返回code为400的*Error
+//
+//	type Client struct {
+//		Token   string `header:"Authorization" validate:"required"`
+//		Version int    `header:"X-App-Version"`
+//	}
+func (self *Context) BindHeader(v interface{}) error {
+	return bindValues(v, "header", self.Req.Header.Values)
+}
+
+// 判断参数是否存在
+func (self *Params) Has(name string) bool {
+	return len(self.get(name)) > 0
+}
+
+// 获取同名的所有参数
+func (self *Params) Strings(name string) []string {
+	return self.get(name)
+}
+
+// 获取参数
+func (self *Params) String(name string) (string, error) {
+	vals := self.get(name)
+	if len(vals) == 0 {
+		return "", NewError(400, "缺少"+self.source+"："+name)
+	}
+	return vals[0], nil
+}
+
+// 获取参数转换成int
+func (self *Params) Int(name string) (int, error) {
+	n, err := self.parseInt(name, strconv.IntSize)
+	return int(n), err
+}
+
+// 获取参数转换成int64
+func (self *Params) Int64(name string) (int64, error) {
+	return self.parseInt(name, 64)
+}
+
+// 获取参数转换成float64
+func (self *Params) Float64(name string) (float64, error) {
+	s, err := self.String(name)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, self.invalid(name)
+	}
+	return f, nil
+}
+
+// 获取参数转换成bool
+func (self *Params) Bool(name string) (bool, error) {
+	s, err := self.String(name)
+	if err != nil {
+		return false, err
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(s))
+	if err != nil {
+		return false, self.invalid(name)
+	}
+	return b, nil
+}
+
+// 获取参数，参数不存在时返回code为400的结果
+func (self *Params) MustString(name string) string {
+	s, err := self.String(name)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// 获取参数转换成int，参数不存在或格式错误时返回code为400的结果
+func (self *Params) MustInt(name string) int {
+	n, err := self.Int(name)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// 获取参数转换成int64，参数不存在或格式错误时返回code为400的结果
+func (self *Params) MustInt64(name string) int64 {
+	n, err := self.Int64(name)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// 获取参数转换成float64，参数不存在或格式错误时返回code为400的结果
+func (self *Params) MustFloat64(name string) float64 {
+	f, err := self.Float64(name)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// 获取参数转换成bool，参数不存在或格式错误时返回code为400的结果
+func (self *Params) MustBool(name string) bool {
+	b, err := self.Bool(name)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// 获取参数转换成整数
+func (self *Params) parseInt(name string, bitSize int) (int64, error) {
+	s, err := self.String(name)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, bitSize)
+	if err != nil {
+		return 0, self.invalid(name)
+	}
+	return n, nil
+}
+
+// 参数格式错误
+func (self *Params) invalid(name string) error {
+	return NewError(400, self.source+"格式错误："+name)
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// RFC 7807格式错误结果的Content-Type
+const problemContentType = "application/problem+json;charset=utf-8"
+
+// RFC 7807格式的错误结果，code、error_id及details为扩展字段
+type Problem struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty"`
+	Code     int         `json:"code"`
+	ErrorId  string      `json:"error_id,omitempty"`
+	Details  interface{} `json:"details,omitempty"`
+}
+
+// 路由级别的RFC 7807错误格式：code不为200时输出application/problem+json（全局开启使用server.problem）
+// 注册路由时识别，因此全局中间件的错误及超时也使用该格式
+// 客户端的Accept中包含application/problem+json时，所有错误结果（包括找不到接口）都使用该格式
+//
+//	cos.GROUP("/partner", func() {
+//		cos.GET("/orders", cosine.ProblemResponse, func(ctx *cosine.Context) (interface{}, error) {...})
+//	})
+func ProblemResponse(ctx *Context) {
+	ctx.mu.Lock()
+	ctx.problem = true
+	ctx.mu.Unlock()
+}
+
+// 判断处理器中是否包含ProblemResponse
+func hasProblemResponse(handlers []Handler) bool {
+	p := reflect.ValueOf(ProblemResponse).Pointer()
+	for _, h := range handlers {
+		if v := reflect.ValueOf(h); v.Kind() == reflect.Func && v.Pointer() == p {
+			return true
+		}
+	}
+	return false
+}
+
+// 判断Accept中是否包含application/problem+json
+func acceptsProblem(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		if mt, q := parseQuality(part); mt == "application/problem+json" && q > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// 判断是否使用RFC 7807错误格式
+func (self *Context) isProblem(out *Response) bool {
+	if out.Code == 200 {
+		return false
+	}
+	if self.Cosine.status.problem {
+		return true
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.problem
+}
+
+// 将错误结果转换成RFC 7807格式，HTTP状态码小于400时使用code（code不是HTTP状态码时为400）
+func (self *Context) toProblem(out *Response) (int, *Problem) {
+	status := self.Cosine.httpStatus(out)
+	if status < 400 {
+		status = http.StatusBadRequest
+		if out.Code >= 400 && out.Code <= 599 {
+			status = out.Code
+		}
+	}
+	return status, &Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   out.Message,
+		Instance: self.Req.URL.Path,
+		Code:     out.Code,
+		ErrorId:  out.ErrorId,
+		Details:  out.Details,
+	}
+}
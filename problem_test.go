@@ -0,0 +1,53 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProblemBeforeRouting(t *testing.T) {
+	cos := New()
+	cos.Use(func(ctx *Context) error {
+		if ctx.Req.URL.Query().Get("fail") != "" {
+			return NewError(10001, "middleware")
+		}
+		return nil
+	})
+	cos.GET("/ok", func() string { return "ok" })
+	cos.GET("/partner", ProblemResponse, func() string { return "ok" })
+
+	for _, c := range []struct{ path, accept, want string }{
+		{"/missing", "application/problem+json", `"status":404`},
+		{"/ok?fail=1", "application/problem+json", `"detail":"middleware"`},
+		{"/ok?fail=1", "application/json, application/problem+json", `"code":10001`},
+		{"/partner?fail=1", "", `"detail":"middleware"`},
+	} {
+		r := httptest.NewRequest("GET", c.path, nil)
+		r.Header.Set("Accept", c.accept)
+		w := httptest.NewRecorder()
+		cos.ServeHTTP(w, r)
+		if ct := w.Header().Get("Content-Type"); ct != problemContentType || !strings.Contains(w.Body.String(), c.want) {
+			t.Errorf("%s %s: got %s %s", c.path, c.accept, ct, w.Body.String())
+		}
+	}
+
+	// 未要求RFC 7807格式时使用默认的返回结果
+	if got := serve(cos, "GET", "/missing"); !strings.HasPrefix(got, `{"code":404`) {
+		t.Errorf("got %s", got)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"mime"
+	neturl "net/url"
+)
+
+// 获取url中的查询参数（结果在本次请求中缓存）
+func (self *Context) queryValues() neturl.Values {
+	if self.query == nil {
+		self.query = self.Req.URL.Query()
+	}
+	return self.query
+}
+
+// 获取application/x-www-form-urlencoded格式提交的表单参数（结果在本次请求中缓存）
+func (self *Context) formValues() neturl.Values {
+	if self.form == nil {
+		self.form = neturl.Values{}
+		ct, _, _ := mime.ParseMediaType(self.Req.Header.Get("Content-Type"))
+		if ct == "application/x-www-form-urlencoded" {
+			if vals, err := neturl.ParseQuery(string(self.Data())); err == nil {
+				self.form = vals
+			}
+		}
+	}
+	return self.form
+}
+
+// 获取查询参数，参数不存在时返回默认值
+func (self *Context) Query(name string, def ...string) string {
+	if vals, ok := self.queryValues()[name]; ok && len(vals) > 0 {
+		return vals[0]
+	}
+	if len(def) > 0 {
+		return def[0]
+	}
+	return ""
+}
+
+// 获取查询参数转换成int，参数不存在且没有默认值或格式错误时返回code为400的*Error
+func (self *Context) QueryInt(name string, def ...int) (int, error) {
+	p := self.QueryParams()
+	if !p.Has(name) && len(def) > 0 {
+		return def[0], nil
+	}
+	return p.Int(name)
+}
+
+// 获取查询参数转换成bool，参数不存在且没有默认值或格式错误时返回code为400的*Error
+func (self *Context) QueryBool(name string, def ...bool) (bool, error) {
+	p := self.QueryParams()
+	if !p.Has(name) && len(def) > 0 {
+		return def[0], nil
+	}
+	return p.Bool(name)
+}
+
+// 获取同名的所有查询参数，如：?id=1&id=2
+func (self *Context) QueryArray(name string) []string {
+	return self.queryValues()[name]
+}
+
+// 将查询参数绑定到结构体（使用query标签），绑定或校验失败时返回code为400的*Error
+//
+//	type Filter struct {
+//		Keyword string   `query:"keyword"`
+//		Size    int      `query:"size" default:"20" validate:"max=100"`
+//		Ids     []int64  `query:"id"`
+//	}
+func (self *Context) BindQuery(v interface{}) error {
+	return bindValues(v, "query", func(name string) []string {
+		return self.queryValues()[name]
+	})
+}
+
+// 获取表单参数，参数不存在时返回默认值
+func (self *Context) Form(name string, def ...string) string {
+	if vals, ok := self.formValues()[name]; ok && len(vals) > 0 {
+		return vals[0]
+	}
+	if len(def) > 0 {
+		return def[0]
+	}
+	return ""
+}
+
+// 获取同名的所有表单参数
+func (self *Context) FormArray(name string) []string {
+	return self.formValues()[name]
+}
+
+// 将表单参数绑定到结构体（使用form标签），绑定或校验失败时返回code为400的*Error
+func (self *Context) BindForm(v interface{}) error {
+	return bindValues(v, "form", func(name string) []string {
+		return self.formValues()[name]
+	})
+}
@@ -0,0 +1,106 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"time"
+)
+
+// 处理器中发生的panic
+type Panic struct {
+	Id    string      // 错误ID，同时返回给客户端，用于关联日志
+	Value interface{} // panic的值
+	Stack []byte      // 调用栈
+}
+
+// panic处理器（可用于自定义返回结果、告警等）
+type PanicHandler func(ctx *Context, p *Panic)
+
+// 添加panic处理器，按添加顺序在记录日志并设置ErrorWrapper后执行
+func (self *Cosine) OnPanic(h PanicHandler) {
+	self.panicHandlers = append(self.panicHandlers, h)
+}
+
+// 生成错误ID
+func newErrorId() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// 执行处理器，并恢复处理器中的panic
+func (self *Context) serve(handlers []*handler) {
+	defer func() {
+		if err := recover(); err != nil {
+			// 保留net/http中止请求的约定
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+			// panic(*Error)作为业务异常返回
+			if e, ok := err.(error); ok && errors.As(e, new(*Error)) {
+				self.errorWrapper(e)
+				return
+			}
+			self.recover(err)
+		}
+	}()
+
+	// 依次执行全局handlers和路由handlers，返回错误时终止后续handlers
+	if self.run(self.Cosine.handlers) {
+		self.run(handlers)
+	}
+}
+
+// 记录panic日志并设置返回结果
+func (self *Context) recover(v interface{}) {
+	self.recoverTo(self.Res, v)
+}
+
+// 记录panic日志并将res设置为服务器内部错误
+func (self *Context) recoverTo(res *Response, v interface{}) {
+	p := &Panic{
+		Id:    newErrorId(),
+		Value: v,
+		Stack: debug.Stack(),
+	}
+	self.logger.Error("panic - " + p.Id + " - " + self.Req.Method + " - " + self.Req.URL.Path + " - " + fmt.Sprint(v) + "\n" + string(p.Stack))
+
+	res.ErrorWrapper()
+	res.ErrorId = p.Id
+
+	// 执行自定义panic处理器
+	for _, h := range self.panicHandlers {
+		self.callPanicHandler(h, p)
+	}
+}
+
+// 执行自定义panic处理器，处理器自身的panic只记录日志
+func (self *Context) callPanicHandler(h PanicHandler, p *Panic) {
+	defer func() {
+		if err := recover(); err != nil {
+			self.logger.Error("panic处理器异常 - " + p.Id + " - " + fmt.Sprint(err))
+		}
+	}()
+	h(self, p)
+}
@@ -0,0 +1,216 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 加载dir目录（包含子目录）中的.html、.tmpl文件，模板名称为相对路径，如：admin/index.html
+// dir为空时返回nil，模板解析失败时panic
+func loadTemplates(dir string) *template.Template {
+	if dir == "" {
+		return nil
+	}
+	t := template.New("")
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if ext := filepath.Ext(path); ext != ".html" && ext != ".tmpl" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		_, err = t.New(filepath.ToSlash(rel)).Parse(string(b))
+		return err
+	})
+	if err != nil {
+		panic("Cosine加载HTML模板失败：" + err.Error())
+	}
+	return t
+}
+
+// 非JSON的返回内容（不使用返回结果封装，处理器返回的数据将被忽略，返回错误时仍输出错误结果）
+type content struct {
+	contentType string
+	data        []byte
+	reader      io.Reader
+	file        string
+	location    string
+}
+
+// 设置返回纯文本
+func (self *Response) Text(s string) {
+	self.Blob("text/plain;charset=utf-8", []byte(s))
+}
+
+// 使用template.dir目录中的模板返回HTML，name为模板文件的相对路径
+func (self *Response) HTML(name string, data interface{}) error {
+	if self.templates == nil {
+		return errors.New("Cosine使用HTML模板时必须配置template.dir")
+	}
+	var buf bytes.Buffer
+	if err := self.templates.ExecuteTemplate(&buf, name, data); err != nil {
+		return err
+	}
+	self.Blob("text/html;charset=utf-8", buf.Bytes())
+	return nil
+}
+
+// 设置返回二进制数据
+func (self *Response) Blob(contentType string, data []byte) {
+	self.DataWrapper(nil)
+	self.content = &content{contentType: contentType, data: data}
+}
+
+// 设置返回r中的数据（r实现了io.Closer时输出后关闭），适合导出大文件
+func (self *Response) Stream(contentType string, r io.Reader) {
+	self.DataWrapper(nil)
+	self.content = &content{contentType: contentType, reader: r}
+}
+
+// 设置返回文件（支持Range及If-Modified-Since），文件不存在时返回code为404的*Error
+func (self *Response) File(path string) error {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return NewError(404, "文件不存在")
+	}
+	self.DataWrapper(nil)
+	self.content = &content{file: path}
+	return nil
+}
+
+// 设置以附件形式下载，在Blob、Stream或File之后调用，name为下载的文件名
+//
+//	if err := ctx.Res.File("/data/report.csv"); err != nil {
+//		return err
+//	}
+//	ctx.Res.Attachment("报表.csv")
+func (self *Response) Attachment(name string) {
+	self.SetHeader("Content-Disposition", "attachment; filename=\""+strings.NewReplacer(`"`, "", "\\", "", "\r", "", "\n", "").Replace(name)+
+		"\"; filename*=UTF-8''"+neturl.PathEscape(name))
+}
+
+// 设置重定向，status为0时使用302
+func (self *Response) Redirect(location string, status int) {
+	if status == 0 {
+		status = http.StatusFound
+	}
+	self.DataWrapper(nil)
+	self.Status = status
+	self.content = &content{location: location}
+}
+
+// 记录输出的状态码及字节数
+type countWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+// 实现http.ResponseWriter接口
+func (self *countWriter) WriteHeader(status int) {
+	if self.status == 0 {
+		self.status = status
+	}
+	self.ResponseWriter.WriteHeader(status)
+}
+
+// 实现http.ResponseWriter接口
+func (self *countWriter) Write(b []byte) (int, error) {
+	if self.status == 0 {
+		self.status = http.StatusOK
+	}
+	n, err := self.ResponseWriter.Write(b)
+	self.bytes += n
+	return n, err
+}
+
+// 输出非JSON的返回内容，返回HTTP状态码及输出的字节数
+// 输出时发生panic（如Stream的读取器）记录日志，尚未输出时按服务器内部错误返回
+func (self *Context) writeContent(w http.ResponseWriter, r *http.Request, out *Response, status int) (written, n int) {
+	c := out.content
+	cw := &countWriter{ResponseWriter: w}
+	defer func() {
+		if err := recover(); err != nil {
+			self.recoverTo(out, err)
+			if cw.status == 0 {
+				_, res, contentType := self.renderDefault(out)
+				w.Header().Del("Content-Disposition")
+				w.Header().Set("Content-Type", contentType)
+				cw.WriteHeader(self.httpStatus(out))
+				cw.Write(res)
+			}
+			written, n = cw.status, cw.bytes
+		}
+	}()
+	if c.contentType != "" {
+		w.Header().Set("Content-Type", c.contentType)
+	}
+
+	switch {
+	case c.location != "":
+		http.Redirect(cw, r, c.location, status)
+	case c.file != "":
+		f, err := os.Open(c.file)
+		if err != nil {
+			w.Header().Del("Content-Disposition")
+			http.NotFound(cw, r)
+			break
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			http.Error(cw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			break
+		}
+		http.ServeContent(cw, r, info.Name(), info.ModTime(), f)
+	case c.reader != nil:
+		if rc, ok := c.reader.(io.Closer); ok {
+			defer rc.Close()
+		}
+		if status != http.StatusOK {
+			cw.WriteHeader(status)
+		}
+		io.Copy(cw, c.reader)
+	default:
+		data := self.compressBody(cw, r, c.data)
+		if status != http.StatusOK {
+			cw.WriteHeader(status)
+		}
+		cw.Write(data)
+	}
+
+	if cw.status == 0 {
+		cw.status = status
+	}
+	return cw.status, cw.bytes
+}
@@ -0,0 +1,85 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	t.Setenv("template.dir", "testdata/templates")
+	cos := New()
+	cos.GET("/html", func(ctx *Context) error {
+		return ctx.Res.HTML("admin/index.html", "<x>")
+	})
+	if got := serve(cos, "GET", "/html"); got != "<h1>&lt;x&gt;</h1>\n" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestHTMLWithoutTemplateDir(t *testing.T) {
+	t.Setenv("template.dir", "")
+	cos := New()
+	cos.GET("/html", func(ctx *Context) error {
+		return ctx.Res.HTML("index.html", nil)
+	})
+	// 每次请求都返回相同的错误
+	for i := 0; i < 2; i++ {
+		if got := serve(cos, "GET", "/html"); !strings.Contains(got, `"code":500`) {
+			t.Fatalf("request %d: got %s", i, got)
+		}
+	}
+}
+
+func TestLoadTemplatesParseError(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	loadTemplates("testdata/badtemplates")
+}
+
+func TestContentWithReturnValues(t *testing.T) {
+	cos := New()
+	cos.GET("/text", func(ctx *Context) (interface{}, error) {
+		ctx.Res.Text("hi")
+		return nil, nil
+	})
+	cos.GET("/redirect", func(ctx *Context) (interface{}, error) {
+		ctx.Res.Redirect("/login", 0)
+		return "ignored", nil
+	})
+	cos.GET("/error", func(ctx *Context) (interface{}, error) {
+		ctx.Res.Text("hi")
+		return nil, NewError(10001, "bad")
+	})
+
+	if got := serve(cos, "GET", "/text"); got != "hi" {
+		t.Errorf("text: got %q", got)
+	}
+
+	w := httptest.NewRecorder()
+	cos.ServeHTTP(w, httptest.NewRequest("GET", "/redirect", nil))
+	if w.Code != 302 || w.Header().Get("Location") != "/login" {
+		t.Errorf("redirect: got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	if got := serve(cos, "GET", "/error"); got != `{"code":10001,"message":"bad","data":null}` {
+		t.Errorf("error: got %s", got)
+	}
+}
@@ -14,51 +14,175 @@
 
 package cosine
 
+import (
+	"html/template"
+	"net/http"
+	"os"
+)
+
 // Cosine返回值封装
 type Response struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data"`
+	Code    int               `json:"code"`
+	Message string            `json:"message"`
+	Data    interface{}       `json:"data"`
+	Meta    interface{}       `json:"meta,omitempty"`  // 分页信息（PageMeta或CursorMeta）
+	Links   map[string]string `json:"links,omitempty"` // 分页链接，输出时根据请求地址生成
+	Details interface{}       `json:"details,omitempty"`
+	ErrorId string            `json:"error_id,omitempty"`
+	Status  int               `json:"-"` // HTTP状态码，0表示根据code确定（见server.httpstatus）
+
+	header  http.Header
+	cookies []*http.Cookie
+	raw     bool        // 是否原样输出数据
+	content *content    // 非JSON的返回内容
+	page    *pagination // 分页信息
+
+	templates *template.Template // HTML模板
+}
+
+// 获取返回的HTTP头
+func (self *Response) Header() http.Header {
+	if self.header == nil {
+		self.header = make(http.Header)
+	}
+	return self.header
+}
+
+// 设置返回的HTTP头
+func (self *Response) SetHeader(name, value string) {
+	self.Header().Set(name, value)
+}
+
+// 添加返回的HTTP头
+func (self *Response) AddHeader(name, value string) {
+	self.Header().Add(name, value)
+}
+
+// 设置Cookie，默认Path为“/”、HttpOnly、SameSite=Lax，https协议时默认Secure
+// 返回的*http.Cookie在输出前都可以修改，maxAge小于0表示删除Cookie
+func (self *Response) SetCookie(name, value string, maxAge int) *http.Cookie {
+	c := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   os.Getenv("server.protocol") == "https",
+		SameSite: http.SameSiteLaxMode,
+	}
+	self.cookies = append(self.cookies, c)
+	return c
+}
+
+// 将HTTP头和Cookie写入http.ResponseWriter
+func (self *Response) writeHeader(w http.ResponseWriter) {
+	for name, vals := range self.header {
+		w.Header()[name] = vals
+	}
+	for _, c := range self.cookies {
+		http.SetCookie(w, c)
+	}
+}
+
+// 设置HTTP状态码
+func (self *Response) SetStatus(status int) {
+	self.Status = status
+}
+
+// 设置返回“已创建”（HTTP状态码201），location不为空时设置Location头
+func (self *Response) Created(data interface{}, location string) {
+	self.DataWrapper(data)
+	self.Status = http.StatusCreated
+	if location != "" {
+		self.SetHeader("Location", location)
+	}
+}
+
+// 设置返回“已接受”（HTTP状态码202）
+func (self *Response) Accepted(data interface{}) {
+	self.DataWrapper(data)
+	self.Status = http.StatusAccepted
+}
+
+// 设置返回“无内容”（HTTP状态码204，不输出返回结果）
+func (self *Response) NoContent() {
+	self.DataWrapper(nil)
+	self.Status = http.StatusNoContent
+}
+
+// 重置返回结果（不包括HTTP头、Cookie及原样输出设置）
+func (self *Response) reset(code int, message string) {
+	self.Code = code
+	self.Message = message
+	self.Data = nil
+	self.Details = nil
+	self.Status = 0
+	self.content = nil
+	self.Meta = nil
+	self.Links = nil
+	self.page = nil
 }
 
 // 设置正确的返回结果
+// 保留已设置的成功状态码（如：先调用SetStatus(201)再返回数据），错误状态码（400及以上）重置为0
 func (self *Response) DataWrapper(data interface{}) {
-	self.Code = 200
-	self.Message = ""
+	status := self.Status
+	self.reset(200, "")
+	if status < 400 {
+		self.Status = status
+	}
 	self.Data = data
 }
 
+// 设置原样输出的返回结果（不封装，只输出data本身，可使用json.RawMessage输出指定的JSON）
+func (self *Response) RawWrapper(data interface{}) {
+	self.DataWrapper(data)
+	self.raw = true
+}
+
 // 设置业务异常的返回结果
 func (self *Response) ExceptionWrapper(code int, message string) {
-	self.Code = code
-	self.Message = message
-	self.Data = nil
+	self.reset(code, message)
 }
 
 // 设置返回“找不到请求的API”
 func (self *Response) NotFoundWrapper() {
-	self.Code = 404
-	self.Message = "找不到请求的API"
-	self.Data = nil
+	self.reset(404, "找不到请求的API")
 }
 
 // 设置返回“服务器内部错误”
 func (self *Response) ErrorWrapper() {
-	self.Code = 500
-	self.Message = "服务器内部错误"
-	self.Data = nil
+	self.reset(500, "服务器内部错误")
 }
 
 // 设置返回“API访问权限不足”
 func (self *Response) ForbiddenWrapper() {
-	self.Code = 403
-	self.Message = "API访问权限不足"
-	self.Data = nil
+	self.reset(403, "API访问权限不足")
+}
+
+// 设置返回“请求数据过大”
+func (self *Response) TooLargeWrapper() {
+	self.reset(413, "请求数据过大")
+}
+
+// 设置返回“不支持请求的返回数据格式”（Accept中没有可接受的格式）
+func (self *Response) NotAcceptableWrapper() {
+	self.reset(406, "不支持请求的返回数据格式")
+}
+
+// 设置返回“请求处理超时”
+func (self *Response) TimeoutWrapper() {
+	self.reset(504, "请求处理超时")
 }
 
 // 设置返回“超过API访问频次限制”
 func (self *Response) LimitZoneWrapper() {
-	self.Code = 503
-	self.Message = "超过API访问频次限制"
-	self.Data = nil
+	self.reset(503, "超过API访问频次限制")
+}
+
+// 设置Error对应的返回结果
+func (self *Response) errorWrapper(e *Error) {
+	self.ExceptionWrapper(e.Code, e.Message)
+	self.Details = e.Details
+	self.Status = e.Status
 }
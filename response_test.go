@@ -0,0 +1,32 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import "testing"
+
+func TestDataWrapperStatus(t *testing.T) {
+	res := new(Response)
+	res.SetStatus(201)
+	res.DataWrapper(1)
+	if res.Status != 201 {
+		t.Fatalf("got status %d, want 201", res.Status)
+	}
+
+	res.errorWrapper(&Error{Code: 10001, Message: "bad", Status: 409, Details: "d"})
+	res.DataWrapper(2)
+	if res.Status != 0 || res.Code != 200 || res.Details != nil {
+		t.Fatalf("got %+v", res)
+	}
+}
@@ -14,14 +14,20 @@
 
 package cosine
 
-import "strings"
+import (
+	"strings"
+	"time"
+)
 
 // url信息结构体
 type url struct {
 	path     string
 	parts    []string
 	wild     bool
-	handlers []Handler
+	handlers []*handler
+	timeout  time.Duration
+	maxBody  int64
+	problem  bool // 是否使用RFC 7807错误格式（处理器中包含ProblemResponse）
 }
 
 // 路由结构体
@@ -80,22 +86,25 @@ func (self *Router) handle(method, path string, handlers []Handler) {
 		path,
 		strings.Split(path[1:], "/"),
 		path[len(path)-1:] == "*",
-		handlers,
+		compileAll(handlers),
+		envDuration("server.timeout." + path),
+		envSize("server.maxbody." + path),
+		hasProblemResponse(handlers),
 	}
 	self.urls[method] = append(self.urls[method], u)
 }
 
-// 匹配请求对应的处理器&获取url地址中的参数
-func (self *Router) match(method, path string) ([]Handler, map[string]interface{}, bool) {
+// 匹配请求对应的url&获取url地址中的参数
+func (self *Router) match(method, path string) (*url, map[string]interface{}, bool) {
 	segments := strings.Split(path[1:], "/")
 	for _, url := range self.urls[method] {
 		// 全匹配
 		if url.path == path {
-			return url.handlers, nil, true
+			return url, nil, true
 		}
 		// 尝试匹配带有通配符(*)和参数的请求
 		if vars, ok := self.try(url, segments); ok {
-			return url.handlers, vars, true
+			return url, vars, true
 		}
 	}
 
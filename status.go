@@ -0,0 +1,70 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"net/http"
+	"os"
+	"strings"
+)
+
+// HTTP状态码配置
+type statusConfig struct {
+	follow  bool        // HTTP状态码是否跟随返回结果的code（server.httpstatus）
+	codes   map[int]int // 业务code对应的HTTP状态码
+	problem bool        // 是否全局使用RFC 7807错误格式（server.problem）
+}
+
+// 读取HTTP状态码配置
+func newStatusConfig() *statusConfig {
+	return &statusConfig{
+		follow:  strings.ToLower(os.Getenv("server.httpstatus")) == "true",
+		codes:   make(map[int]int),
+		problem: strings.ToLower(os.Getenv("server.problem")) == "true",
+	}
+}
+
+// 设置业务code对应的HTTP状态码，如：MapStatus(10001, 409)
+// 未开启server.httpstatus时也生效
+func (self *Cosine) MapStatus(code, status int) {
+	if status < 200 || status > 599 {
+		panic("Cosine要求HTTP状态码必须在200~599之间")
+	}
+	self.status.codes[code] = status
+}
+
+// 获取返回结果对应的HTTP状态码
+// 优先使用Response.Status，其次为MapStatus设置的状态码
+// 开启server.httpstatus时，code在100~599之间的直接作为HTTP状态码，其余业务code为200
+// 1xx（信息性状态码，不能作为最终的返回结果）及超出范围的状态码返回500
+func (self *Cosine) httpStatus(out *Response) int {
+	status := http.StatusOK
+	if out.Status != 0 {
+		status = out.Status
+	} else if mapped, ok := self.status.codes[out.Code]; ok {
+		status = mapped
+	} else if self.status.follow && out.Code >= 100 && out.Code <= 599 {
+		status = out.Code
+	}
+	if status < 200 || status > 599 {
+		return http.StatusInternalServerError
+	}
+	return status
+}
+
+// 判断HTTP状态码是否不允许有返回内容
+func bodyless(status int) bool {
+	return status == http.StatusNoContent || status == http.StatusNotModified
+}
@@ -0,0 +1,43 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import "testing"
+
+func TestHTTPStatus(t *testing.T) {
+	t.Setenv("server.httpstatus", "true")
+	cos := New()
+	cos.MapStatus(10001, 409)
+
+	for _, c := range []struct {
+		res  *Response
+		want int
+	}{
+		{&Response{Code: 200}, 200},
+		{&Response{Code: 404}, 404},
+		{&Response{Code: 10001}, 409},
+		{&Response{Code: 20001}, 200},
+		{&Response{Code: 101}, 500},
+		{&Response{Code: 200, Status: 201}, 201},
+		{&Response{Code: 200, Status: 100}, 500},
+		{&Response{Code: 200, Status: 1000}, 500},
+	} {
+		if got := cos.httpStatus(c.res); got != c.want {
+			t.Errorf("code %d status %d: got %d, want %d", c.res.Code, c.res.Status, got, c.want)
+		}
+	}
+
+	mustPanic(t, "1xx", func() { cos.MapStatus(10002, 101) })
+}
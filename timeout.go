@@ -0,0 +1,68 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// context.Context类型
+var stdContextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// 实现context.Context接口：截止时间
+func (self *Context) Deadline() (time.Time, bool) {
+	return self.std.Deadline()
+}
+
+// 实现context.Context接口：请求超时或客户端断开连接时关闭
+func (self *Context) Done() <-chan struct{} {
+	return self.std.Done()
+}
+
+// 实现context.Context接口：请求超时或客户端断开连接的原因
+func (self *Context) Err() error {
+	return self.std.Err()
+}
+
+// 实现context.Context接口：获取上下文中的值
+func (self *Context) Value(key interface{}) interface{} {
+	return self.std.Value(key)
+}
+
+// 在超时限制内执行处理器，超时或客户端断开连接时返回false（处理器仍会在后台执行完毕）
+func (self *Context) serveTimeout(handlers []*handler) bool {
+	done := make(chan interface{}, 1)
+	self.running = make(chan struct{})
+	go func() {
+		defer close(self.running)
+		defer func() {
+			done <- recover()
+		}()
+		self.serve(handlers)
+	}()
+
+	select {
+	case p := <-done:
+		// 在当前goroutine中重新抛出net/http中止请求的panic
+		if p != nil {
+			panic(p)
+		}
+		return true
+	case <-self.Done():
+		return false
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeoutStopsBodyReads(t *testing.T) {
+	t.Setenv("server.timeout", "20ms")
+	cos := New()
+	result := make(chan error, 1)
+	cos.POST("/slow", func(ctx *Context) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		_, err := ctx.ReadData()
+		result <- err
+	})
+
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	w := httptest.NewRecorder()
+	cos.ServeHTTP(w, httptest.NewRequest("POST", "/slow", pr))
+	if !strings.Contains(w.Body.String(), `"code":504`) {
+		t.Fatalf("got %s", w.Body.String())
+	}
+	if err := <-result; err != context.DeadlineExceeded {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestTimeoutDuringBodyRead(t *testing.T) {
+	t.Setenv("server.timeout", "50ms")
+	t.Setenv("server.httpstatus", "true")
+	cos := New()
+	result := make(chan error, 1)
+	cos.POST("/slow", func(ctx *Context) {
+		_, err := ctx.ReadData()
+		result <- err
+	})
+	srv := httptest.NewServer(cos)
+	defer srv.Close()
+
+	// 客户端只发送部分请求数据（slowloris）
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go pw.Write([]byte("partial"))
+	start := time.Now()
+	res, err := http.Post(srv.URL+"/slow", "text/plain", pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("got status %d", res.StatusCode)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("timeout response took %s", d)
+	}
+	select {
+	case err := <-result:
+		if err != context.DeadlineExceeded {
+			t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("body read was not interrupted")
+	}
+}
+
+func TestCancelAfterHooks(t *testing.T) {
+	t.Setenv("server.timeout", "1s")
+	cos := New()
+	done := make(chan struct{})
+	finished := make(chan *Finish, 1)
+	cos.GET("/cancel", func(ctx *Context) {
+		ctx.After(func(f *Finish) {
+			finished <- f
+		})
+		<-ctx.Done()
+		// 处理器在后台继续修改返回结果
+		for i := 0; i < 100; i++ {
+			ctx.Res.DataWrapper(i)
+		}
+		close(done)
+	})
+
+	std, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest("GET", "/cancel", nil).WithContext(std)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	cos.ServeHTTP(httptest.NewRecorder(), r)
+
+	f := <-finished
+	if f.Status != 0 || f.Response.Data != nil {
+		t.Fatalf("got status %d, data %v", f.Status, f.Response.Data)
+	}
+	<-done
+}
@@ -0,0 +1,282 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// multipart表单中普通字段的总大小限制
+const maxFormValueSize = 10 << 20
+
+// 上传文件数量超过限制（upload.maxfiles）
+var ErrTooManyFiles = NewError(413, "上传文件数量超过限制")
+
+// 上传文件超过大小限制（upload.maxsize）
+var ErrFileTooLarge = NewError(413, "上传文件过大")
+
+// 上传配置
+type uploadConfig struct {
+	dir      string // 临时文件目录，默认为系统临时目录
+	memory   int64  // 所有文件在内存中缓存的总字节数，用完后写入临时文件
+	maxSize  int64  // 单个文件的最大字节数，0表示不限制
+	maxFiles int    // 文件数量限制，0表示不限制
+}
+
+// 默认的上传文件数量限制
+const defaultMaxFiles = 100
+
+// 读取上传配置
+func newUploadConfig() *uploadConfig {
+	conf := &uploadConfig{
+		dir:      os.Getenv("upload.tempdir"),
+		memory:   envSize("upload.memory"),
+		maxSize:  envSize("upload.maxsize"),
+		maxFiles: defaultMaxFiles,
+	}
+	if conf.memory == 0 {
+		conf.memory = 1 << 20
+	}
+	if s := os.Getenv("upload.maxfiles"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			panic("upload.maxfiles配置错误")
+		}
+		conf.maxFiles = n
+	}
+	return conf
+}
+
+// 上传的文件
+type Upload struct {
+	Field    string               // 表单字段名
+	Filename string               // 客户端提交的文件名（不含路径）
+	Header   textproto.MIMEHeader // 文件的MIME头
+	Size     int64                // 文件大小
+
+	data []byte // 内存中的文件内容
+	path string // 临时文件路径
+}
+
+// 打开上传的文件
+func (self *Upload) Open() (io.ReadCloser, error) {
+	if self.path != "" {
+		return os.Open(self.path)
+	}
+	return ioutil.NopCloser(bytes.NewReader(self.data)), nil
+}
+
+// 使用Storage保存上传的文件，返回保存后的路径
+func (self *Upload) SaveTo(s Storage, name string) (string, error) {
+	r, err := self.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	return s.Save(name, r)
+}
+
+// 文件存储
+type Storage interface {
+	// 保存文件，返回保存后的路径
+	Save(name string, r io.Reader) (string, error)
+}
+
+// 本地文件存储
+type LocalStorage struct {
+	Dir string
+}
+
+// 获取本地文件存储实例
+func NewLocalStorage(dir string) *LocalStorage {
+	return &LocalStorage{Dir: dir}
+}
+
+// 保存文件到Dir目录下，name中的“..”不会超出Dir目录
+func (self *LocalStorage) Save(name string, r io.Reader) (string, error) {
+	path := filepath.Join(self.Dir, filepath.Clean("/"+name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// 解析后的multipart表单
+type multipartForm struct {
+	values map[string][]string
+	files  []*Upload
+	err    error
+}
+
+// 删除临时文件
+func (self *multipartForm) removeAll() {
+	for _, u := range self.files {
+		if u.path != "" {
+			os.Remove(u.path)
+		}
+	}
+}
+
+// 获取指定字段上传的第一个文件，没有上传时返回code为400的*Error
+func (self *Context) File(field string) (*Upload, error) {
+	files, err := self.Files()
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range files {
+		if u.Field == field {
+			return u, nil
+		}
+	}
+	return nil, NewError(400, "缺少上传文件："+field)
+}
+
+// 获取所有上传的文件
+func (self *Context) Files() ([]*Upload, error) {
+	form, err := self.multipartForm()
+	if err != nil {
+		return nil, err
+	}
+	return form.files, nil
+}
+
+// 解析multipart/form-data表单（结果在本次请求中缓存，处理器执行完毕且请求结束后删除临时文件）
+func (self *Context) multipartForm() (*multipartForm, error) {
+	if self.multipart == nil {
+		self.multipart = &multipartForm{values: make(map[string][]string)}
+		self.onCleanup(self.multipart.removeAll)
+		self.multipart.err = self.parseMultipart(self.multipart)
+	}
+	return self.multipart, self.multipart.err
+}
+
+// 逐个读取表单中的字段，所有文件共用upload.memory的内存缓存，用完后写入临时文件
+func (self *Context) parseMultipart(form *multipartForm) error {
+	mt, params, err := mime.ParseMediaType(self.Req.Header.Get("Content-Type"))
+	if err != nil || mt != "multipart/form-data" || params["boundary"] == "" {
+		return badData()
+	}
+
+	conf := self.Cosine.upload
+	valueSize := int64(0)
+	memory := conf.memory
+	mr := multipart.NewReader(self.BodyReader(), params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return multipartErr(err)
+		}
+
+		name := part.FormName()
+		if name == "" {
+			continue
+		}
+
+		// 普通字段
+		if part.FileName() == "" {
+			b, err := ioutil.ReadAll(io.LimitReader(part, maxFormValueSize-valueSize+1))
+			if err != nil {
+				return multipartErr(err)
+			}
+			if valueSize += int64(len(b)); valueSize > maxFormValueSize {
+				return ErrBodyTooLarge
+			}
+			form.values[name] = append(form.values[name], string(b))
+			continue
+		}
+
+		// 文件
+		if conf.maxFiles > 0 && len(form.files) >= conf.maxFiles {
+			return ErrTooManyFiles
+		}
+		u := &Upload{Field: name, Filename: part.FileName(), Header: part.Header}
+		form.files = append(form.files, u)
+		if err := spool(u, part, conf, memory); err != nil {
+			return err
+		}
+		memory -= int64(len(u.data))
+	}
+}
+
+// 读取上传的文件，超过剩余的内存缓存（memory）时写入临时文件
+func spool(u *Upload, r io.Reader, conf *uploadConfig, memory int64) error {
+	if conf.maxSize > 0 {
+		r = io.LimitReader(r, conf.maxSize+1)
+	}
+
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, r, memory+1)
+	if err != nil && err != io.EOF {
+		return multipartErr(err)
+	}
+	u.Size = n
+	if n <= memory {
+		u.data = buf.Bytes()
+		if conf.maxSize > 0 && u.Size > conf.maxSize {
+			return ErrFileTooLarge
+		}
+		return nil
+	}
+
+	// 写入临时文件
+	f, err := ioutil.TempFile(conf.dir, "cosine-upload-")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	u.path = f.Name()
+	if _, err = f.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	n, err = io.Copy(f, r)
+	if err != nil {
+		return multipartErr(err)
+	}
+	u.Size += n
+	if conf.maxSize > 0 && u.Size > conf.maxSize {
+		return ErrFileTooLarge
+	}
+	return nil
+}
+
+// 转换读取表单时的错误
+func multipartErr(err error) error {
+	if errors.Is(err, ErrBodyTooLarge) {
+		return ErrBodyTooLarge
+	}
+	return badData()
+}
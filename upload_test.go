@@ -0,0 +1,184 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// 创建上传一个文件的请求
+func uploadRequest(t *testing.T, path string, size int) *http.Request {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile("file", "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(bytes.Repeat([]byte("a"), size))
+	mw.Close()
+	r := httptest.NewRequest("POST", path, &buf)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+// 获取上传文件的临时文件路径
+func spooledPath(t *testing.T, ctx *Context) string {
+	u, err := ctx.File("file")
+	if err != nil {
+		t.Error(err)
+		return ""
+	}
+	if u.path == "" {
+		t.Error("file was not spooled to disk")
+	}
+	return u.path
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestUploadCleanup(t *testing.T) {
+	t.Setenv("upload.memory", "10")
+	cos := New()
+	var path string
+	cos.POST("/upload", func(ctx *Context) {
+		path = spooledPath(t, ctx)
+	})
+	cos.ServeHTTP(httptest.NewRecorder(), uploadRequest(t, "/upload", 100))
+	if path == "" || exists(path) {
+		t.Fatalf("temp file %q was not removed", path)
+	}
+}
+
+func TestUploadCleanupOnAbort(t *testing.T) {
+	t.Setenv("upload.memory", "10")
+	cos := New()
+	var path string
+	cos.POST("/upload", func(ctx *Context) {
+		path = spooledPath(t, ctx)
+		panic(http.ErrAbortHandler)
+	})
+	func() {
+		defer func() {
+			if recover() != http.ErrAbortHandler {
+				t.Error("expected http.ErrAbortHandler")
+			}
+		}()
+		cos.ServeHTTP(httptest.NewRecorder(), uploadRequest(t, "/upload", 100))
+	}()
+	if path == "" || exists(path) {
+		t.Fatalf("temp file %q was not removed", path)
+	}
+}
+
+func TestUploadCleanupAfterTimeout(t *testing.T) {
+	t.Setenv("upload.memory", "10")
+	t.Setenv("server.timeout", "20ms")
+	cos := New()
+	paths := make(chan string, 1)
+	readErr := make(chan error, 1)
+	cos.POST("/upload", func(ctx *Context) {
+		u, _ := ctx.File("file")
+		paths <- spooledPath(t, ctx)
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+		// 超时后处理器仍可以读取临时文件
+		f, err := u.Open()
+		if err == nil {
+			_, err = ioutil.ReadAll(f)
+			f.Close()
+		}
+		readErr <- err
+	})
+
+	w := httptest.NewRecorder()
+	cos.ServeHTTP(w, uploadRequest(t, "/upload", 100))
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`"code":504`)) {
+		t.Fatalf("got %d %s", w.Code, w.Body.String())
+	}
+	path := <-paths
+	if err := <-readErr; err != nil {
+		t.Fatalf("read temp file after timeout: %v", err)
+	}
+	for i := 0; exists(path); i++ {
+		if i == 100 {
+			t.Fatalf("temp file %q was not removed", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// 创建上传多个文件的请求
+func uploadFilesRequest(t *testing.T, path string, count, size int) *http.Request {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for i := 0; i < count; i++ {
+		fw, err := mw.CreateFormFile("file", "a.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write(bytes.Repeat([]byte("a"), size))
+	}
+	mw.Close()
+	r := httptest.NewRequest("POST", path, &buf)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func TestUploadMemoryBudget(t *testing.T) {
+	t.Setenv("upload.memory", "1000")
+	cos := New()
+	var inMemory, spooled int
+	cos.POST("/upload", func(ctx *Context) error {
+		files, err := ctx.Files()
+		if err != nil {
+			return err
+		}
+		for _, u := range files {
+			inMemory += len(u.data)
+			if u.path != "" {
+				spooled++
+			}
+		}
+		return nil
+	})
+
+	cos.ServeHTTP(httptest.NewRecorder(), uploadFilesRequest(t, "/upload", 50, 100))
+	if inMemory > 1000 || spooled != 40 {
+		t.Fatalf("in memory %d bytes, spooled %d files", inMemory, spooled)
+	}
+}
+
+func TestUploadDefaultMaxFiles(t *testing.T) {
+	cos := New()
+	var err error
+	cos.POST("/upload", func(ctx *Context) {
+		_, err = ctx.Files()
+	})
+
+	cos.ServeHTTP(httptest.NewRecorder(), uploadFilesRequest(t, "/upload", defaultMaxFiles+1, 1))
+	if err != ErrTooManyFiles {
+		t.Fatalf("got %v, want %v", err, ErrTooManyFiles)
+	}
+}
@@ -0,0 +1,335 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// 字段校验失败信息
+type FieldError struct {
+	Field  string `json:"field"`           // 字段路径，如：items[0].name
+	Reason string `json:"reason"`          // 未通过的校验规则，如：required
+	Param  string `json:"param,omitempty"` // 校验规则参数，如：min=1中的1
+}
+
+// 校验规则函数，v为字段值（指针已解引用），param为规则参数
+type ValidatorFunc func(v reflect.Value, param string) bool
+
+// 校验规则
+var (
+	validatorsMu sync.RWMutex
+	validators   = map[string]ValidatorFunc{
+		"min":   validateMin,
+		"max":   validateMax,
+		"len":   validateLen,
+		"email": validateEmail,
+		"oneof": validateOneOf,
+	}
+)
+
+// 邮箱格式
+var emailRegexp = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+// 参数必须是数字的内置校验规则（被RegisterValidator覆盖后不再检查）
+var numericRules = map[string]bool{"min": true, "max": true, "len": true}
+
+// 结构体的校验规则（按类型缓存，第一次校验或注册处理器时解析）
+var structRules sync.Map
+
+// 字段的校验规则
+type fieldRules struct {
+	index    int    // 字段索引
+	name     string // 字段名称（优先使用json标签）
+	embedded bool   // 是否为没有validate标签的嵌入字段（与外层共用路径）
+	required bool
+	rules    []rule // 除required外的规则
+}
+
+// 校验规则
+type rule struct {
+	name    string
+	param   string
+	numeric bool // 是否为内置的数值规则（min、max、len），零值时也校验
+}
+
+// 注册自定义校验规则，如：RegisterValidator("mobile", func(v reflect.Value, param string) bool {...})
+// 需要在注册使用该规则的处理器之前调用
+func RegisterValidator(name string, fn ValidatorFunc) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+	validators[name] = fn
+	delete(numericRules, name)
+}
+
+// 获取结构体的校验规则，规则不存在或参数错误时panic
+func rulesOf(t reflect.Type) []fieldRules {
+	if fs, ok := structRules.Load(t); ok {
+		return fs.([]fieldRules)
+	}
+
+	var fs []fieldRules
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		tag := f.Tag.Get("validate")
+		fr := fieldRules{index: i, name: fieldName(f), embedded: f.Anonymous && tag == ""}
+		if tag != "-" {
+			fr.required, fr.rules = parseRules(t.String()+"."+f.Name, tag)
+		}
+		fs = append(fs, fr)
+	}
+	structRules.Store(t, fs)
+	return fs
+}
+
+// 解析validate标签
+func parseRules(field, tag string) (bool, []rule) {
+	validatorsMu.RLock()
+	defer validatorsMu.RUnlock()
+
+	required := false
+	var rules []rule
+	for _, s := range strings.Split(tag, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if s == "required" {
+			required = true
+			continue
+		}
+		r := rule{name: s}
+		if i := strings.Index(s, "="); i >= 0 {
+			r.name, r.param = s[:i], s[i+1:]
+		}
+		if _, ok := validators[r.name]; !ok {
+			panic("Cosine找不到校验规则：" + r.name + "（" + field + "）")
+		}
+		if r.numeric = numericRules[r.name]; r.numeric {
+			if _, err := strconv.ParseFloat(r.param, 64); err != nil {
+				panic("Cosine校验规则参数错误：" + s + "（" + field + "）")
+			}
+		}
+		rules = append(rules, r)
+	}
+	return required, rules
+}
+
+// 检查类型中所有结构体的validate标签（注册处理器时调用，标签错误时panic）
+func checkRules(t reflect.Type) {
+	checkRulesOf(t, make(map[reflect.Type]bool))
+}
+
+// 递归检查validate标签
+func checkRulesOf(t reflect.Type, visited map[reflect.Type]bool) {
+	if visited[t] {
+		return
+	}
+	visited[t] = true
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		checkRulesOf(t.Elem(), visited)
+	case reflect.Struct:
+		for _, fr := range rulesOf(t) {
+			checkRulesOf(t.Field(fr.index).Type, visited)
+		}
+	}
+}
+
+// 根据validate标签校验结构体，校验失败时返回code为400的*Error，details为所有未通过校验的字段
+//
+//	type P struct {
+//		Name  string   `json:"name" validate:"required,min=1,max=64"`
+//		Email string   `json:"email" validate:"email"`
+//		Sex   string   `json:"sex" validate:"oneof=male female"`
+//		Tags  []string `json:"tags" validate:"max=10"`
+//	}
+//
+// 规则按声明顺序校验；未标记required的字段为零值时只校验min、max、len（如min=1不允许0），
+// nil指针、切片及map视为未提交，跳过所有规则（可选的数值字段使用指针类型）；嵌套的结构体、切片及map中的结构体会递归校验
+// 校验规则按类型缓存，规则不存在或参数错误时panic（嵌入Body的请求数据类型在注册处理器时检查）
+func Validate(v interface{}) error {
+	var errs []FieldError
+	validateValue(reflect.ValueOf(v), "", &errs)
+	if len(errs) > 0 {
+		return NewError(400, "请求参数校验失败").WithDetails(errs)
+	}
+	return nil
+}
+
+// 递归校验
+func validateValue(v reflect.Value, path string, errs *[]FieldError) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		fs := rulesOf(v.Type())
+		for i := range fs {
+			fr := &fs[i]
+			fv := v.Field(fr.index)
+
+			// 嵌入的结构体与外层共用路径
+			if fr.embedded {
+				validateValue(fv, path, errs)
+				continue
+			}
+
+			name := fr.name
+			if path != "" {
+				name = path + "." + name
+			}
+			validateField(fv, fr, name, errs)
+			validateValue(fv, name, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), path+"["+strconv.Itoa(i)+"]", errs)
+		}
+	case reflect.Map:
+		// 按key排序，保证错误顺序固定
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		for _, k := range keys {
+			validateValue(v.MapIndex(k), path+"["+fmt.Sprint(k)+"]", errs)
+		}
+	}
+}
+
+// 获取字段名称（优先使用json标签）
+func fieldName(f reflect.StructField) string {
+	if tag := f.Tag.Get("json"); tag != "" && tag != "-" {
+		if name := strings.Split(tag, ",")[0]; name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// 按validate标签校验单个字段
+func validateField(v reflect.Value, fr *fieldRules, name string, errs *[]FieldError) {
+	if !fr.required && len(fr.rules) == 0 {
+		return
+	}
+
+	// 零值字段：未标记required时只校验数值规则，未提交（nil）时跳过
+	zero := v.IsZero()
+	if zero && fr.required {
+		*errs = append(*errs, FieldError{Field: name, Reason: "required"})
+		return
+	}
+	if zero && isNil(v) {
+		return
+	}
+
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	for _, r := range fr.rules {
+		if zero && !r.numeric {
+			continue
+		}
+		validatorsMu.RLock()
+		fn := validators[r.name]
+		validatorsMu.RUnlock()
+		if !fn(v, r.param) {
+			*errs = append(*errs, FieldError{Field: name, Reason: r.name, Param: r.param})
+		}
+	}
+}
+
+// 判断是否为nil（指针、接口、切片、map等）
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map, reflect.Chan, reflect.Func:
+		return v.IsNil()
+	}
+	return false
+}
+
+// 获取用于比较大小的数值：字符串为字符数，切片和map为长度，数字为数值本身
+func measure(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// 比较数值与规则参数
+func compare(v reflect.Value, param string, fn func(n, p float64) bool) bool {
+	p, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic("Cosine校验规则参数错误：" + param)
+	}
+	n, ok := measure(v)
+	return ok && fn(n, p)
+}
+
+// 最小值
+func validateMin(v reflect.Value, param string) bool {
+	return compare(v, param, func(n, p float64) bool { return n >= p })
+}
+
+// 最大值
+func validateMax(v reflect.Value, param string) bool {
+	return compare(v, param, func(n, p float64) bool { return n <= p })
+}
+
+// 长度
+func validateLen(v reflect.Value, param string) bool {
+	return compare(v, param, func(n, p float64) bool { return n == p })
+}
+
+// 邮箱
+func validateEmail(v reflect.Value, param string) bool {
+	return v.Kind() == reflect.String && emailRegexp.MatchString(v.String())
+}
+
+// 枚举值，多个值以空格分隔
+func validateOneOf(v reflect.Value, param string) bool {
+	s := fmt.Sprint(v)
+	for _, opt := range strings.Fields(param) {
+		if s == opt {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+// Copyright 2016 mxie916@163.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cosine
+
+import (
+	"reflect"
+	"testing"
+)
+
+type validateItem struct {
+	Name string `json:"name" validate:"required,max=3"`
+}
+
+type validateStruct struct {
+	Email string         `json:"email" validate:"email"`
+	Sex   string         `json:"sex" validate:"oneof=male female"`
+	Size  int            `json:"size" validate:"min=1,max=10"`
+	Items []validateItem `json:"items" validate:"max=2"`
+	Skip  string         `validate:"-"`
+}
+
+func TestValidate(t *testing.T) {
+	v := &validateStruct{
+		Email: "bad",
+		Sex:   "x",
+		Size:  11,
+		Items: []validateItem{{Name: "abcd"}, {}},
+	}
+	err := Validate(v)
+	e, ok := err.(*Error)
+	if !ok || e.Code != 400 {
+		t.Fatalf("got %v", err)
+	}
+	want := []FieldError{
+		{Field: "email", Reason: "email"},
+		{Field: "sex", Reason: "oneof", Param: "male female"},
+		{Field: "size", Reason: "max", Param: "10"},
+		{Field: "items[0].name", Reason: "max", Param: "3"},
+		{Field: "items[1].name", Reason: "required"},
+	}
+	if !reflect.DeepEqual(e.Details, want) {
+		t.Fatalf("got %+v", e.Details)
+	}
+
+	if err := Validate(&validateStruct{Size: 5, Items: []validateItem{{Name: "a"}}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateZeroValues(t *testing.T) {
+	type optional struct {
+		Count int               `json:"count" validate:"min=1"`
+		Limit *int              `json:"limit" validate:"min=1"`
+		Email string            `json:"email" validate:"email"`
+		Name  string            `json:"name" validate:"max=3,email"`
+		Items map[string]string `json:"items" validate:"min=1"`
+		Sub   map[string]validateItem
+	}
+
+	zero := 0
+	want := []FieldError{
+		{Field: "count", Reason: "min", Param: "1"},
+		{Field: "limit", Reason: "min", Param: "1"},
+		{Field: "name", Reason: "max", Param: "3"},
+		{Field: "name", Reason: "email"},
+		{Field: "Sub[a].name", Reason: "required"},
+		{Field: "Sub[b].name", Reason: "required"},
+		{Field: "Sub[c].name", Reason: "required"},
+	}
+	// 错误顺序固定
+	for i := 0; i < 20; i++ {
+		err := Validate(&optional{Limit: &zero, Name: "abcd", Sub: map[string]validateItem{"b": {}, "a": {}, "c": {}}})
+		e, ok := err.(*Error)
+		if !ok || !reflect.DeepEqual(e.Details, want) {
+			t.Fatalf("got %v", err)
+		}
+	}
+	if err := Validate(&optional{Count: 1}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 检查是否panic
+func mustPanic(t *testing.T, name string, fn func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s: expected panic", name)
+		}
+	}()
+	fn()
+}
+
+func TestValidateTagErrors(t *testing.T) {
+	type unknownRule struct {
+		Name string `validate:"requierd"`
+	}
+	type badParam struct {
+		Size int `validate:"min=a"`
+	}
+	type bodyTypo struct {
+		Body
+		Items []unknownRule
+	}
+
+	// 与字段的值无关，第一次校验时即panic
+	mustPanic(t, "unknown rule", func() { Validate(&unknownRule{}) })
+	mustPanic(t, "bad param", func() { Validate(&badParam{}) })
+
+	// 请求数据类型在注册处理器时检查
+	cos := New()
+	mustPanic(t, "register", func() {
+		cos.POST("/typo", func(b *bodyTypo) {})
+	})
+}